@@ -1,27 +1,63 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/DieGopherLT/refaccionaria-backend/internal/alert"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/controller"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/driver"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/invoice"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/repository/postgre"
 	"github.com/joho/godotenv"
 )
 
+// lowStockInterval time between checks of products that reached their reorder point
+const lowStockInterval = 10 * time.Minute
+
 func main() {
 
-	postgresConnectionURl, port := os.Getenv("DATABASE_URL"), os.Getenv("PORT")
+	postgresConnectionURl, port, taxRate := os.Getenv("DATABASE_URL"), os.Getenv("PORT"), os.Getenv("IVA_RATE")
+	costingMethod := os.Getenv("COSTING_METHOD")
+	issuer := invoice.Issuer{
+		RFC:       os.Getenv("CFDI_RFC"),
+		Name:      os.Getenv("CFDI_NOMBRE"),
+		TaxRegime: os.Getenv("CFDI_REGIMEN"),
+		ZipCode:   os.Getenv("CFDI_CP"),
+		Series:    os.Getenv("CFDI_SERIE"),
+	}
 	if postgresConnectionURl == "" || port == "" {
 		envs, err := LoadEnvironmentVariables(".env")
 		if err != nil {
 			log.Fatalln("could not load environment variables", err.Error())
 		}
-		postgresConnectionURl, port = envs["DATABASE_URL"], envs["PORT"]
+		postgresConnectionURl, port, taxRate = envs["DATABASE_URL"], envs["PORT"], envs["IVA_RATE"]
+		costingMethod = envs["COSTING_METHOD"]
+		issuer = invoice.Issuer{
+			RFC:       envs["CFDI_RFC"],
+			Name:      envs["CFDI_NOMBRE"],
+			TaxRegime: envs["CFDI_REGIMEN"],
+			ZipCode:   envs["CFDI_CP"],
+			Series:    envs["CFDI_SERIE"],
+		}
+	}
+
+	calculator, err := BuildPriceCalculator(taxRate)
+	if err != nil {
+		log.Fatalln("invalid IVA_RATE", err.Error())
+	}
+
+	costing, err := BuildCostingMethod(costingMethod)
+	if err != nil {
+		log.Fatalln("invalid COSTING_METHOD", err.Error())
 	}
 
 	postgresSqlBuilder := postgre.NewBuilder()
@@ -31,8 +67,14 @@ func main() {
 	}
 	defer db.Close()
 
-	postgreRepo := postgre.NewRepository(db)
-	repo := controller.NewHandlersRepo(postgreRepo)
+	postgreRepo := postgre.NewRepository(db, calculator, costing)
+
+	stockChecker := alert.NewStockChecker(postgreRepo, alert.NewLogNotifier(nil), lowStockInterval)
+	go stockChecker.Run(context.Background())
+
+	// No PAC is contracted yet, invoices are stamped locally without fiscal validity
+	invoices := invoice.NewService(issuer, invoice.NewFakePAC(), calculator.TaxRate)
+	repo := controller.NewHandlersRepo(postgreRepo, invoices)
 	controller.SetHandlersRepo(repo)
 
 	server := http.Server{
@@ -73,3 +115,33 @@ func BuildDatabasePool(builder driver.DatabasePoolConnectionBuilder, connectionU
 	fmt.Println("Postgres database connected")
 	return db.GetPool(), nil
 }
+
+// BuildPriceCalculator builds the sale price calculator, an empty tax rate falls back to the default IVA
+func BuildPriceCalculator(taxRate string) (pricing.Calculator, error) {
+	if taxRate == "" {
+		return pricing.NewCalculator(pricing.DefaultTaxRate), nil
+	}
+
+	rate, err := strconv.ParseFloat(taxRate, 32)
+	if err != nil {
+		return pricing.Calculator{}, err
+	}
+
+	if rate < 0 || rate >= 1 {
+		return pricing.Calculator{}, fmt.Errorf("tax rate %v out of range", rate)
+	}
+
+	return pricing.NewCalculator(float32(rate)), nil
+}
+
+// BuildCostingMethod picks the method the cost of goods sold is recorded with, weighted average when none is given
+func BuildCostingMethod(method string) (string, error) {
+	switch method {
+	case "":
+		return models.CostingWeightedAverage, nil
+	case models.CostingWeightedAverage, models.CostingFIFO:
+		return method, nil
+	}
+
+	return "", fmt.Errorf("costing method %q not supported", method)
+}
@@ -34,6 +34,37 @@ func Routes() http.Handler {
 				r.Post("/", controller.Repo.PostProduct)
 				r.Put("/", controller.Repo.PutProduct)
 				r.Delete("/", controller.Repo.DeleteProduct)
+				r.Get("/low-stock", controller.Repo.GetLowStockProducts)
+				r.Get("/lookup", controller.Repo.GetProductLookup)
+				r.Post("/fitment/import", controller.Repo.PostFitmentImport)
+				r.Post("/price-update", controller.Repo.PostPriceUpdate)
+				r.Get("/price-suggestion", controller.Repo.GetPriceSuggestion)
+				r.Get("/{id}/kardex", controller.Repo.GetProductKardex)
+				r.Get("/{id}/price-history", controller.Repo.GetProductPriceHistory)
+				r.Get("/{id}/providers", controller.Repo.GetProductProviders)
+				r.Put("/{id}/providers", controller.Repo.PutProductProvider)
+				r.Delete("/{id}/providers/{providerId}", controller.Repo.DeleteProductProvider)
+				r.Put("/{id}/codes", controller.Repo.PutProductCodes)
+				r.Get("/{id}/cross-reference", controller.Repo.GetProductCrossReferences)
+				r.Post("/{id}/cross-reference", controller.Repo.PostCrossReference)
+				r.Delete("/{id}/cross-reference/{crossReferenceId}", controller.Repo.DeleteCrossReference)
+				r.Get("/{id}/fitment", controller.Repo.GetProductFitment)
+				r.Put("/{id}/fitment", controller.Repo.PutProductFitment)
+				r.Delete("/{id}/fitment/{vehicleId}", controller.Repo.DeleteProductFitment)
+			})
+
+			r.Route("/markup-rule", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetMarkupRules)
+				r.Post("/", controller.Repo.PostMarkupRule)
+				r.Put("/{id}", controller.Repo.PutMarkupRule)
+				r.Delete("/{id}", controller.Repo.DeleteMarkupRule)
+			})
+
+			r.Route("/vehicle", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetVehicles)
+				r.Post("/", controller.Repo.PostVehicle)
+				r.Put("/{id}", controller.Repo.PutVehicle)
+				r.Delete("/{id}", controller.Repo.DeleteVehicle)
 			})
 
 			r.Route("/provider", func(r chi.Router) {
@@ -48,6 +79,43 @@ func Routes() http.Handler {
 				r.Post("/", controller.Repo.PostSale)
 				r.Put("/", controller.Repo.PutSale)
 				r.Delete("/", controller.Repo.DeleteSale)
+				r.Get("/report", controller.Repo.GetSalesReport)
+				r.Post("/{id}/payment", controller.Repo.PostSalePayment)
+				r.Post("/{id}/invoice", controller.Repo.PostSaleInvoice)
+			})
+
+			r.Route("/invoice", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetInvoices)
+				r.Get("/{id}/xml", controller.Repo.GetInvoiceXML)
+				r.Get("/{id}/pdf", controller.Repo.GetInvoicePDF)
+			})
+
+			r.Route("/return", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetReturns)
+				r.Post("/", controller.Repo.PostReturn)
+			})
+
+			r.Route("/register", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetRegisterSessions)
+				r.Post("/open", controller.Repo.PostOpenRegister)
+				r.Get("/{id}", controller.Repo.GetRegisterSession)
+				r.Post("/{id}/movement", controller.Repo.PostCashMovement)
+				r.Post("/{id}/close", controller.Repo.PostCloseRegister)
+			})
+
+			r.Route("/quote", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetQuotes)
+				r.Post("/", controller.Repo.PostQuote)
+				r.Delete("/", controller.Repo.DeleteQuote)
+				r.Get("/{id}/print", controller.Repo.GetQuotePrint)
+				r.Post("/{id}/convert", controller.Repo.PostConvertQuote)
+			})
+
+			r.Route("/promotion", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetPromotions)
+				r.Post("/", controller.Repo.PostPromotion)
+				r.Put("/", controller.Repo.PutPromotion)
+				r.Delete("/", controller.Repo.DeletePromotion)
 			})
 
 			r.Route("/delivery", func(r chi.Router) {
@@ -56,19 +124,63 @@ func Routes() http.Handler {
 				r.Delete("/", controller.Repo.DeleteDelivery)
 			})
 
+			r.Route("/purchase-order", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetPurchaseOrders)
+				r.Post("/", controller.Repo.PostPurchaseOrder)
+				r.Get("/{id}", controller.Repo.GetPurchaseOrder)
+				r.Put("/{id}", controller.Repo.PutPurchaseOrder)
+				r.Post("/{id}/send", controller.Repo.PostSendPurchaseOrder)
+				r.Post("/{id}/cancel", controller.Repo.PostCancelPurchaseOrder)
+				r.Get("/{id}/receipt", controller.Repo.GetPurchaseOrderReceipts)
+				r.Post("/{id}/receipt", controller.Repo.PostPurchaseOrderReceipt)
+			})
+
+			r.Route("/inventory-count", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetInventoryCounts)
+				r.Post("/", controller.Repo.PostInventoryCount)
+				r.Get("/{id}", controller.Repo.GetInventoryCount)
+				r.Post("/{id}/entries", controller.Repo.PostCountEntries)
+				r.Post("/{id}/post", controller.Repo.PostCountApproval)
+				r.Post("/{id}/cancel", controller.Repo.PostCancelCount)
+			})
+
+			r.Get("/inventory/valuation", controller.Repo.GetInventoryValuation)
+
+			r.Route("/branch", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetBranches)
+				r.Post("/", controller.Repo.PostBranch)
+				r.Put("/{id}", controller.Repo.PutBranch)
+			})
+
+			r.Route("/transfer", func(r chi.Router) {
+				r.Get("/", controller.Repo.GetTransfers)
+				r.Post("/", controller.Repo.PostTransfer)
+				r.Post("/{id}/receive", controller.Repo.PostReceiveTransfer)
+				r.Post("/{id}/cancel", controller.Repo.PostCancelTransfer)
+			})
+
 			r.Route("/client", func(r chi.Router) {
 				r.Get("/", controller.Repo.GetClients)
 				r.Post("/", controller.Repo.PostClient)
 				r.Put("/{id}", controller.Repo.PutClient)
 				r.Delete("/{id}", controller.Repo.DeleteClient)
+				r.Get("/{id}/statement", controller.Repo.GetClientStatement)
+				r.Post("/{id}/payment", controller.Repo.PostClientPayment)
+				r.Put("/{id}/tax-data", controller.Repo.PutClientTaxData)
 			})
 
 			r.Route("/brand", func(r chi.Router) {
 				r.Get("/", controller.Repo.GetBrands)
+				r.Post("/", controller.Repo.PostBrand)
+				r.Put("/{id}", controller.Repo.PutBrand)
+				r.Delete("/{id}", controller.Repo.DeleteBrand)
 			})
 
 			r.Route("/category", func(r chi.Router) {
 				r.Get("/", controller.Repo.GetCategories)
+				r.Post("/", controller.Repo.PostCategory)
+				r.Put("/{id}", controller.Repo.PutCategory)
+				r.Delete("/{id}", controller.Repo.DeleteCategory)
 			})
 		})
 
@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Kinds of alert
+const (
+	LowStock = "low_stock"
+)
+
+// Alert something the staff of the store should know about
+type Alert struct {
+	Kind      string    `json:"kind"`
+	ProductID int       `json:"product_id,omitempty"`
+	Message   string    `json:"message"`
+	RaisedAt  time.Time `json:"raised_at"`
+}
+
+// Notifier delivers alerts to whoever must act on them
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// LogNotifier writes alerts to a logger
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a notifier that writes alerts to logger, or to the standard logger when it is nil
+func NewLogNotifier(logger *log.Logger) LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return LogNotifier{logger: logger}
+}
+
+// Notify writes the alert as a single log line
+func (n LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.logger.Printf("[%s] %s", alert.Kind, alert.Message)
+	return nil
+}
+
+// MemoryNotifier keeps the alerts it receives in memory, it is safe for concurrent use
+type MemoryNotifier struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+// NewMemoryNotifier creates a notifier that keeps alerts in memory
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+// Notify stores the alert
+func (n *MemoryNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+// Alerts returns a copy of the alerts received so far, oldest first
+func (n *MemoryNotifier) Alerts() []Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	alerts := make([]Alert, len(n.alerts))
+	copy(alerts, n.alerts)
+	return alerts
+}
@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// LowStockSource gives the products whose stock is at or below their reorder point
+type LowStockSource interface {
+	GetLowStockProducts() ([]models.LowStockProduct, error)
+}
+
+// StockChecker periodically looks for products that reached their reorder point.
+//
+// A product raises an alert when it crosses its reorder point, not on every check while it stays low; once it is
+// restocked above that point it may raise a new one.
+type StockChecker struct {
+	source   LowStockSource
+	notifier Notifier
+	interval time.Duration
+	low      map[int]bool
+}
+
+// NewStockChecker creates a checker that looks at source every interval and raises alerts through notifier
+func NewStockChecker(source LowStockSource, notifier Notifier, interval time.Duration) *StockChecker {
+	return &StockChecker{
+		source:   source,
+		notifier: notifier,
+		interval: interval,
+		low:      make(map[int]bool),
+	}
+}
+
+// Run checks the stock until ctx is done, a failed check is logged and retried on the next tick
+func (c *StockChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Check(ctx); err != nil {
+			log.Println("low stock check failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check raises an alert for every product that reached its reorder point since the previous check, it must not be
+// called concurrently
+func (c *StockChecker) Check(ctx context.Context) error {
+	products, err := c.source.GetLowStockProducts()
+	if err != nil {
+		return err
+	}
+
+	low := make(map[int]bool, len(products))
+	for _, product := range products {
+		low[product.ProductID] = true
+		if c.low[product.ProductID] {
+			continue
+		}
+
+		err := c.notifier.Notify(ctx, Alert{
+			Kind:      LowStock,
+			ProductID: product.ProductID,
+			Message: fmt.Sprintf("%s %s (%d) llegó a su punto de reorden: stock %d, mínimo %d, surtir %d",
+				product.Classification, product.Brand, product.ProductID, product.Amount, product.MinStock, product.Suggested),
+			RaisedAt: time.Now(),
+		})
+		if err != nil {
+			// Keep it out of the known low products so the alert is raised again on the next check
+			delete(low, product.ProductID)
+			log.Println("could not notify low stock:", err)
+		}
+	}
+	c.low = low
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetBranches handler for get request over branch resource
+func (m *Repository) GetBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := m.db.GetAllBranches()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["branches"] = branches
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostBranch handler for post request over branch resource
+func (m *Repository) PostBranch(w http.ResponseWriter, r *http.Request) {
+	var branch models.BranchDTO
+
+	err := json.NewDecoder(r.Body).Decode(&branch)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidBranch(branch)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	branchId, err := m.db.InsertBranch(branch)
+	if handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Sucursal creada"
+	data["branch_id"] = branchId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutBranch handler for put request over branch resource
+func (m *Repository) PutBranch(w http.ResponseWriter, r *http.Request) {
+	branchId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var branch models.BranchDTO
+	err = json.NewDecoder(r.Body).Decode(&branch)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidBranch(branch)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateBranch(branchId, branch)
+	if handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Sucursal no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Sucursal actualizada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetTransfers handler for get request over transfer resource
+func (m *Repository) GetTransfers(w http.ResponseWriter, r *http.Request) {
+	transfers, err := m.db.GetAllTransfers()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["transfers"] = transfers
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostTransfer handler for post request that sends stock from a branch to another
+func (m *Repository) PostTransfer(w http.ResponseWriter, r *http.Request) {
+	var transfer models.TransferDTO
+
+	err := json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidTransfer(transfer)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	transferId, err := m.db.InsertTransfer(transfer)
+	if handledBranchError(w, err) || handledStockError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Traspaso enviado"
+	data["transfer_id"] = transferId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PostReceiveTransfer handler for post request that receives a transfer at its destination
+func (m *Repository) PostReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	transferId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.ReceiveTransfer(transferId)
+	if handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Traspaso recibido", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// PostCancelTransfer handler for post request that cancels a transfer in transit
+func (m *Repository) PostCancelTransfer(w http.ResponseWriter, r *http.Request) {
+	transferId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.CancelTransfer(transferId)
+	if handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Traspaso cancelado", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// handledBranchError writes the response for errors caused by missing branches, repeated branch names and
+// transfers that are missing or no longer in transit.
+//
+// It returns false when the error is not related to branches, so the caller can keep handling it.
+func handledBranchError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrBranchNotFound) {
+		resp := helpers.Response{Message: "Sucursal no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrBranchNameTaken) {
+		resp := helpers.Response{Message: "Ya existe una sucursal con ese nombre", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrTransferNotFound) {
+		resp := helpers.Response{Message: "Traspaso no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrTransferNotInTransit) {
+		resp := helpers.Response{Message: "El traspaso ya no está en tránsito", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// PostBrand handler for post request over brand resource
+func (m *Repository) PostBrand(w http.ResponseWriter, r *http.Request) {
+	var brand models.BrandDTO
+
+	err := json.NewDecoder(r.Body).Decode(&brand)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidBrand(brand)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	brandId, err := m.db.InsertBrand(brand)
+	if handledBrandError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Marca creada"
+	data["brand_id"] = brandId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutBrand handler for put request over brand resource, the incoming aliases replace the current ones
+func (m *Repository) PutBrand(w http.ResponseWriter, r *http.Request) {
+	brandId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var brand models.BrandDTO
+	err = json.NewDecoder(r.Body).Decode(&brand)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidBrand(brand)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateBrand(brandId, brand)
+	if handledBrandError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Marca no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Marca actualizada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// DeleteBrand handler for delete request over brand resource, the merge_into query param is the brand its products
+// move to and its name becomes an alias of
+func (m *Repository) DeleteBrand(w http.ResponseWriter, r *http.Request) {
+	brandId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var mergeInto int
+	if value := r.URL.Query().Get("merge_into"); value != "" {
+		mergeInto, err = strconv.Atoi(value)
+		if err != nil || mergeInto <= 0 || mergeInto == brandId {
+			resp := helpers.Response{Message: "Marca a fusionar no válida", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	rows, err := m.db.DeleteBrand(brandId, mergeInto)
+	if handledBrandError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Marca no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Marca eliminada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// resolveBrand gives a product the id and name of the brand it was sent with, by id or by its name or an alias.
+//
+// It returns false when it already wrote the response because the brand does not exist.
+func (m *Repository) resolveBrand(w http.ResponseWriter, product *models.ProductDTO) bool {
+	brand, err := m.db.ResolveBrand(product.BrandID, product.Brand)
+	if handledBrandError(w, err) {
+		return false
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return false
+	}
+
+	product.BrandID = brand.BrandID
+	product.Brand = brand.Name
+	return true
+}
+
+// handledBrandError writes the response for errors caused by missing brands, repeated names or aliases and brands
+// still in use.
+//
+// It returns false when the error is not related to brands, so the caller can keep handling it.
+func handledBrandError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrBrandNotFound) {
+		resp := helpers.Response{Message: "Marca no encontrada, debe darse de alta primero", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrBrandNameTaken) {
+		resp := helpers.Response{Message: "Ya existe una marca con ese nombre o alias", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrBrandInUse) {
+		resp := helpers.Response{Message: "La marca tiene productos, indica con qué marca fusionarla", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// PostCategory handler for post request over category resource
+func (m *Repository) PostCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.CategoryDTO
+
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCategory(category)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	categoryId, err := m.db.InsertCategory(category)
+	if handledCategoryError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Categoría creada"
+	data["category_id"] = categoryId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutCategory handler for put request over category resource, changing the parent moves the category with every
+// category under it
+func (m *Repository) PutCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var category models.CategoryDTO
+	err = json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCategory(category)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateCategory(categoryId, category)
+	if handledCategoryError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Categoría no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Categoría actualizada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// DeleteCategory handler for delete request over category resource, the reassign_to query param is the category
+// its products move to
+func (m *Repository) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var reassignTo int
+	if value := r.URL.Query().Get("reassign_to"); value != "" {
+		reassignTo, err = strconv.Atoi(value)
+		if err != nil || reassignTo <= 0 || reassignTo == categoryId {
+			resp := helpers.Response{Message: "Categoría a reasignar no válida", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	rows, err := m.db.DeleteCategory(categoryId, reassignTo)
+	if handledCategoryError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Categoría no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Categoría eliminada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// handledCategoryError writes the response for errors caused by missing categories, repeated names, moves that
+// would put a category under itself and categories still in use.
+//
+// It returns false when the error is not related to categories, so the caller can keep handling it.
+func handledCategoryError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		resp := helpers.Response{Message: "Categoría no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCategoryNameTaken) {
+		resp := helpers.Response{Message: "Ya existe una categoría con ese nombre en el mismo nivel", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCategoryCycle) {
+		resp := helpers.Response{Message: "Una categoría no puede moverse dentro de sí misma", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCategoryInUse) {
+		resp := helpers.Response{
+			Message: "La categoría tiene productos o promociones aplicadas en ventas, indica a qué categoría reasignarlos",
+			Error:   true,
+		}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetProductLookup handler for get request that finds the products with an exact SKU, part number, OEM number or
+// barcode, it is what the scanner of the point of sale calls
+func (m *Repository) GetProductLookup(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if strings.TrimSpace(code) == "" {
+		resp := helpers.Response{Message: "Se debe indicar el código a buscar", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	products, err := m.db.LookupProducts(code)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if len(products) == 0 {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["products"] = products
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PutProductCodes handler for put request that replaces the SKU, part numbers and barcodes of a product
+func (m *Repository) PutProductCodes(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var codes models.ProductCodesDTO
+	err = json.NewDecoder(r.Body).Decode(&codes)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidProductCodes(codes)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.SetProductCodes(productId, codes)
+	if handledProductCodeError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Códigos del producto actualizados"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// handledProductCodeError writes the response for errors caused by missing products and by codes that already
+// belong to another product.
+//
+// It returns false when the error is not related to product codes, so the caller can keep handling it.
+func handledProductCodeError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSKUTaken) {
+		resp := helpers.Response{Message: "El SKU ya pertenece a otro producto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrBarcodeTaken) {
+		resp := helpers.Response{Message: "Uno de los códigos de barras ya pertenece a otro producto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -2,11 +2,14 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/invoice"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
@@ -15,15 +18,20 @@ import (
 
 var Repo *Repository
 
+// dateLayout layout of dates received as query params
+const dateLayout = "2006-01-02"
+
 // Repository is a repository that will store all handlers for incoming http requests
 type Repository struct {
-	db repository.DatabaseRepo
+	db       repository.DatabaseRepo
+	invoices invoice.Service
 }
 
-// NewHandlersRepo creates a new repository for handlers with a database pool connection
-func NewHandlersRepo(db repository.DatabaseRepo) *Repository {
+// NewHandlersRepo creates a new repository for handlers with a database pool connection and the invoice service
+func NewHandlersRepo(db repository.DatabaseRepo, invoices invoice.Service) *Repository {
 	return &Repository{
-		db: db,
+		db:       db,
+		invoices: invoices,
 	}
 }
 
@@ -32,9 +40,59 @@ func SetHandlersRepo(r *Repository) {
 	Repo = r
 }
 
-// GetProducts handler for get request over product resource
+// GetProducts handler for get request over product resource, the vehicle query param lists the products that fit
+// a vehicle and year narrows it down to a single model year. The category one lists the products of a category and
+// of the categories under it and the brand one the products of a brand
 func (m *Repository) GetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := m.db.GetAllProducts()
+	filter := models.ProductFilter{}
+	if vehicle := r.URL.Query().Get("vehicle"); vehicle != "" {
+		vehicleId, err := strconv.Atoi(vehicle)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+		filter.VehicleID = vehicleId
+	}
+
+	if year := r.URL.Query().Get("year"); year != "" {
+		number, err := strconv.Atoi(year)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+		filter.Year = number
+	}
+
+	if brand := r.URL.Query().Get("brand"); brand != "" {
+		brandId, err := strconv.Atoi(brand)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+		filter.BrandID = brandId
+	}
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		categoryId, err := strconv.Atoi(category)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+		filter.CategoryID = categoryId
+	}
+
+	products, err := m.db.GetAllProducts(filter)
+	if handledVehicleError(w, err) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Algo salio mal", Error: true}
@@ -59,14 +117,25 @@ func (m *Repository) PostProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emptyField := validator.HasEmptyStringField(product)
-	if emptyField {
-		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+	isValid, resp := validator.IsValidProduct(product)
+	if !isValid {
 		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
 		return
 	}
 
+	if !m.resolveBrand(w, &product) {
+		return
+	}
+
+	warning, ok := m.applyMarkupRule(w, &product)
+	if !ok {
+		return
+	}
+
 	err = m.db.InsertProduct(product)
+	if handledBranchError(w, err) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Algo salio mal", Error: true}
@@ -74,7 +143,16 @@ func (m *Repository) PostProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := helpers.Response{Message: "Producto creado"}
+	if warning != "" {
+		data := make(map[string]interface{})
+		data["message"] = "Producto creado"
+		data["warning"] = warning
+		data["error"] = false
+		helpers.WriteJsonResponse(w, http.StatusCreated, data)
+		return
+	}
+
+	resp = helpers.Response{Message: "Producto creado"}
 	helpers.WriteJsonResponse(w, http.StatusCreated, resp)
 }
 
@@ -97,14 +175,25 @@ func (m *Repository) PutProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emptyField := validator.HasEmptyStringField(product)
-	if emptyField {
-		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+	isValid, resp := validator.IsValidProduct(product)
+	if !isValid {
 		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
 		return
 	}
 
+	if !m.resolveBrand(w, &product) {
+		return
+	}
+
+	warning, ok := m.applyMarkupRule(w, &product)
+	if !ok {
+		return
+	}
+
 	rows, err := m.db.UpdateProduct(productId, product)
+	if handledProductProviderError(w, err) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Error al actualizar el producto", Error: true}
@@ -118,7 +207,16 @@ func (m *Repository) PutProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := helpers.Response{Message: "Producto actualizado"}
+	if warning != "" {
+		data := make(map[string]interface{})
+		data["message"] = "Producto actualizado"
+		data["warning"] = warning
+		data["error"] = false
+		helpers.WriteJsonResponse(w, http.StatusOK, data)
+		return
+	}
+
+	resp = helpers.Response{Message: "Producto actualizado"}
 	helpers.WriteJsonResponse(w, http.StatusOK, resp)
 }
 
@@ -314,7 +412,17 @@ func (m *Repository) PostSale(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = m.db.InsertSale(newSale)
+	isValid, resp := validator.IsValidSale(newSale)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receipt, err := m.db.InsertSale(newSale)
+	if handledSessionError(w, err) || handledStockError(w, err) || handledPaymentError(w, err) ||
+		handledCreditError(w, err) || handledManagerOverrideError(w, err) {
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
@@ -322,8 +430,70 @@ func (m *Repository) PostSale(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := helpers.Response{Message: "Venta agregada exitosamente", Error: false}
-	helpers.WriteJsonResponse(w, http.StatusCreated, resp)
+	data := make(map[string]interface{})
+	data["message"] = "Venta agregada exitosamente"
+	data["receipt"] = receipt
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PostSalePayment handler for post request over the payments of a sale
+func (m *Repository) PostSalePayment(w http.ResponseWriter, r *http.Request) {
+	saleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var payment models.SalePaymentDTO
+	err = json.NewDecoder(r.Body).Decode(&payment)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	if len(payment.Payments) == 0 {
+		resp := helpers.Response{Message: "Debe registrarse al menos un pago", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidPayments(payment.Payments)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receipt, err := m.db.AddSalePayments(saleId, payment)
+	if handledPaymentError(w, err) || handledSessionError(w, err) {
+		return
+	}
+	if errors.Is(err, repository.ErrSaleNotFound) {
+		resp := helpers.Response{Message: "Venta no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrSaleFinalized) {
+		resp := helpers.Response{Message: "La venta ya está pagada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Pago registrado exitosamente"
+	data["receipt"] = receipt
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
 }
 
 // PutSale handler for put request over sale resource
@@ -352,7 +522,31 @@ func (m *Repository) PutSale(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	isValid, resp := validator.IsValidSale(sale)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
 	rows, err := m.db.UpdateSale(saleId, sale)
+	if handledStockError(w, err) || handledCreditError(w, err) || handledManagerOverrideError(w, err) {
+		return
+	}
+	if errors.Is(err, repository.ErrSaleHasReturns) {
+		resp := helpers.Response{Message: "La venta tiene devoluciones, no puede modificarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrSaleInvoiced) {
+		resp := helpers.Response{Message: "La venta ya fue facturada, no puede modificarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrSaleHasPayments) {
+		resp := helpers.Response{Message: "La venta ya tiene pagos, no puede modificarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
@@ -367,7 +561,7 @@ func (m *Repository) PutSale(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := helpers.Response{Message: "Registro actualizado extitosamente", Error: false}
+	resp = helpers.Response{Message: "Registro actualizado extitosamente", Error: false}
 	helpers.WriteJsonResponse(w, http.StatusOK, resp)
 }
 
@@ -382,6 +576,21 @@ func (m *Repository) DeleteSale(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rows, err := m.db.DeleteSale(saleId)
+	if errors.Is(err, repository.ErrSaleHasReturns) {
+		resp := helpers.Response{Message: "La venta tiene devoluciones, no puede eliminarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrSaleInvoiced) {
+		resp := helpers.Response{Message: "La venta ya fue facturada, no puede eliminarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrSaleHasPayments) {
+		resp := helpers.Response{Message: "La venta ya tiene pagos, no puede eliminarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
 	if err != nil {
 		fmt.Println(err)
 		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
@@ -400,6 +609,37 @@ func (m *Repository) DeleteSale(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJsonResponse(w, http.StatusOK, resp)
 }
 
+// GetSalesReport handler for get request over sales report, sales and refunds between from and to dates
+func (m *Repository) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(dateLayout, r.URL.Query().Get("from"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	to, err := time.Parse(dateLayout, r.URL.Query().Get("to"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	report, err := m.db.GetSalesReport(from, to)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+	data := make(map[string]interface{})
+	data["report"] = report
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
 // GetDeliveries handler for get request over delivery resource
 func (m *Repository) GetDeliveries(w http.ResponseWriter, r *http.Request) {
 	deliveries, err := m.db.GetAllDeliveries()
@@ -621,7 +861,7 @@ func (m *Repository) DeleteClient(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJsonResponse(w, http.StatusOK, resp)
 }
 
-// GetBrands handler for get request over brand resource
+// GetBrands handler for get request over brand resource, every brand comes with its aliases
 func (m *Repository) GetBrands(w http.ResponseWriter, r *http.Request) {
 	brands, err := m.db.GetAllBrands()
 	if err != nil {
@@ -650,3 +890,73 @@ func (m *Repository) GetCategories(w http.ResponseWriter, r *http.Request) {
 	data["error"] = false
 	helpers.WriteJsonResponse(w, http.StatusOK, data)
 }
+
+// handledStockError writes the response for errors caused by missing products or not enough stock.
+//
+// It returns false when the error is not related to stock, so the caller can keep handling it.
+func handledStockError(w http.ResponseWriter, err error) bool {
+	var stockErr *repository.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		data := make(map[string]interface{})
+		data["message"] = "Stock insuficiente"
+		data["product_id"] = stockErr.ProductID
+		data["requested"] = stockErr.Requested
+		data["available"] = stockErr.Available
+		data["error"] = true
+		helpers.WriteJsonResponse(w, http.StatusConflict, data)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	return false
+}
+
+// handledPaymentError writes the response for errors caused by payments that do not fit the sale total.
+//
+// It returns false when the error is not related to payments, so the caller can keep handling it.
+func handledPaymentError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrPaymentExceedsTotal) {
+		resp := helpers.Response{Message: "Solo los pagos en efectivo pueden exceder el total de la venta", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return true
+	}
+
+	return false
+}
+
+// handledManagerOverrideError writes the response for price overrides without a valid manager authorization.
+//
+// It returns false when the error is not related to the authorization, so the caller can keep handling it.
+func handledManagerOverrideError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrManagerNotAuthorized) {
+		resp := helpers.Response{Message: "Autorización de gerente no válida", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusForbidden, resp)
+		return true
+	}
+
+	return false
+}
+
+// handledSessionError writes the response for errors caused by missing or closed register sessions.
+//
+// It returns false when the error is not related to register sessions, so the caller can keep handling it.
+func handledSessionError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		resp := helpers.Response{Message: "Sesión de caja no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSessionClosed) {
+		resp := helpers.Response{Message: "La sesión de caja está cerrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
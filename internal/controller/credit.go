@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetClientStatement handler for get request over the account statement of a client
+func (m *Repository) GetClientStatement(w http.ResponseWriter, r *http.Request) {
+	clientId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	statement, err := m.db.GetClientStatement(clientId)
+	if handledCreditError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["statement"] = statement
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostClientPayment handler for post request that applies a payment of a client over its open credit sales
+func (m *Repository) PostClientPayment(w http.ResponseWriter, r *http.Request) {
+	clientId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var payment models.ClientPaymentDTO
+	err = json.NewDecoder(r.Body).Decode(&payment)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	if payment.SessionID <= 0 {
+		resp := helpers.Response{Message: "El pago debe registrarse en una caja abierta", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	tender := models.PaymentDTO{Method: payment.Method, Amount: payment.Amount, Reference: payment.Reference}
+	isValid, resp := validator.IsValidPayments([]models.PaymentDTO{tender})
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receipts, err := m.db.ApplyClientPayment(clientId, payment)
+	if handledCreditError(w, err) || handledSessionError(w, err) {
+		return
+	}
+	if errors.Is(err, repository.ErrPaymentExceedsTotal) {
+		resp := helpers.Response{Message: "El pago excede el saldo del cliente", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Pago aplicado exitosamente"
+	data["receipts"] = receipts
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// handledCreditError writes the response for errors caused by missing clients or credit sales over the limit.
+//
+// It returns false when the error is not related to client credit, so the caller can keep handling it.
+func handledCreditError(w http.ResponseWriter, err error) bool {
+	var creditErr *repository.CreditLimitError
+	if errors.As(err, &creditErr) {
+		data := make(map[string]interface{})
+		data["message"] = "El cliente excede su límite de crédito"
+		data["client_id"] = creditErr.ClientID
+		data["limit"] = creditErr.Limit
+		data["balance"] = creditErr.Balance
+		data["requested"] = creditErr.Requested
+		data["error"] = true
+		helpers.WriteJsonResponse(w, http.StatusConflict, data)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrClientNotFound) {
+		resp := helpers.Response{Message: "Cliente no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	return false
+}
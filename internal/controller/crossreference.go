@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetProductCrossReferences handler for get request that lists the parts a product can be replaced with
+func (m *Repository) GetProductCrossReferences(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	references, err := m.db.GetProductCrossReferences(productId)
+	if handledCrossReferenceError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["cross_references"] = references
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostCrossReference handler for post request that records a part a product can be replaced with
+func (m *Repository) PostCrossReference(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var reference models.CrossReferenceDTO
+	err = json.NewDecoder(r.Body).Decode(&reference)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCrossReference(productId, reference)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	crossReferenceId, err := m.db.InsertCrossReference(productId, reference)
+	if handledCrossReferenceError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Equivalencia registrada"
+	data["cross_reference_id"] = crossReferenceId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// DeleteCrossReference handler for delete request that removes an equivalence of a product
+func (m *Repository) DeleteCrossReference(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	crossReferenceId, err := strconv.Atoi(chi.URLParam(r, "crossReferenceId"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.DeleteCrossReference(productId, crossReferenceId)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Equivalencia no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Equivalencia eliminada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// handledCrossReferenceError writes the response for errors caused by missing products, by equivalences that
+// were already recorded and by products given as equivalences of themselves.
+//
+// It returns false when the error is not related to cross references, so the caller can keep handling it.
+func handledCrossReferenceError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCrossReferenceExists) {
+		resp := helpers.Response{Message: "La equivalencia ya está registrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSelfCrossReference) {
+		resp := helpers.Response{Message: "Un producto no puede ser equivalente de sí mismo", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return true
+	}
+
+	return false
+}
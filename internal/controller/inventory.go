@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetInventoryCounts handler for get request over inventory count resource
+func (m *Repository) GetInventoryCounts(w http.ResponseWriter, r *http.Request) {
+	counts, err := m.db.GetAllInventoryCounts()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["counts"] = counts
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// GetInventoryCount handler for get request over a single inventory count with its variances
+func (m *Repository) GetInventoryCount(w http.ResponseWriter, r *http.Request) {
+	countId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	count, err := m.db.GetInventoryCount(countId)
+	if handledCountError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["count"] = count
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostInventoryCount handler for post request that opens an inventory count
+func (m *Repository) PostInventoryCount(w http.ResponseWriter, r *http.Request) {
+	var count models.InventoryCountDTO
+
+	err := json.NewDecoder(r.Body).Decode(&count)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(count)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidInventoryCount(count)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	countId, err := m.db.InsertInventoryCount(count)
+	if handledCountError(w, err) || handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Conteo de inventario iniciado"
+	data["count_id"] = countId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PostCountEntries handler for post request that uploads a batch of counted units to an open inventory count
+func (m *Repository) PostCountEntries(w http.ResponseWriter, r *http.Request) {
+	countId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var entries models.CountEntriesDTO
+	err = json.NewDecoder(r.Body).Decode(&entries)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCountEntries(entries)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.RecordCountEntries(countId, entries)
+	if handledCountError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Conteo registrado exitosamente", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// PostCountApproval handler for post request that closes an inventory count posting the approved adjustments
+func (m *Repository) PostCountApproval(w http.ResponseWriter, r *http.Request) {
+	countId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var approval models.PostCountDTO
+	err = json.NewDecoder(r.Body).Decode(&approval)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(approval)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "El motivo del ajuste es obligatorio", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCountApproval(approval)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	adjusted, err := m.db.PostInventoryCount(countId, approval)
+	if handledCountError(w, err) || handledStockError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Ajustes de inventario aplicados"
+	data["adjusted"] = adjusted
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostCancelCount handler for post request that cancels an open inventory count, stock is not touched
+func (m *Repository) PostCancelCount(w http.ResponseWriter, r *http.Request) {
+	countId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.CancelInventoryCount(countId)
+	if handledCountError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Conteo de inventario cancelado", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// handledCountError writes the response for errors caused by missing inventory counts, counts already closed and
+// products that are not part of a count.
+//
+// It returns false when the error is not related to inventory counts, so the caller can keep handling it.
+func handledCountError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrCountNotFound) {
+		resp := helpers.Response{Message: "Conteo de inventario no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCountNotOpen) {
+		resp := helpers.Response{Message: "El conteo de inventario ya fue cerrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrCountLineNotFound) {
+		resp := helpers.Response{Message: "Uno de los productos no forma parte del conteo", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	return false
+}
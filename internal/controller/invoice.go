@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/invoice"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// PutClientTaxData handler for put request over the tax data of a client
+func (m *Repository) PutClientTaxData(w http.ResponseWriter, r *http.Request) {
+	clientId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var taxData models.TaxDataDTO
+	err = json.NewDecoder(r.Body).Decode(&taxData)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(taxData)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidTaxData(taxData)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateClientTaxData(clientId, taxData)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Cliente no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Datos fiscales actualizados exitosamente", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetInvoices handler for get request over invoice resource
+func (m *Repository) GetInvoices(w http.ResponseWriter, r *http.Request) {
+	invoices, err := m.db.GetAllInvoices()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["invoices"] = invoices
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostSaleInvoice handler for post request that issues and stamps the invoice of a sale
+func (m *Repository) PostSaleInvoice(w http.ResponseWriter, r *http.Request) {
+	saleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	inv, err := m.db.ReserveInvoice(saleId, m.invoices.Series(), m.invoices.TaxRate())
+	if handledInvoiceError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	// The folio stays reserved when stamping fails, so the invoice can be stamped again later
+	inv, err = m.invoices.Stamp(r.Context(), inv)
+	var validationErr *invoice.ValidationError
+	if errors.As(err, &validationErr) {
+		data := make(map[string]interface{})
+		data["message"] = "La factura no cumple con el esquema del CFDI"
+		data["problems"] = validationErr.Problems
+		data["error"] = true
+		helpers.WriteJsonResponse(w, http.StatusUnprocessableEntity, data)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "No se pudo timbrar la factura", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadGateway, resp)
+		return
+	}
+
+	err = m.db.StampInvoice(inv)
+	if handledInvoiceError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Factura timbrada exitosamente"
+	data["invoice"] = inv
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// GetInvoiceXML handler for get request over the stamped XML of an invoice
+func (m *Repository) GetInvoiceXML(w http.ResponseWriter, r *http.Request) {
+	inv, ok := m.stampedInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%d.xml\"", inv.Series, inv.Folio))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(inv.XML))
+}
+
+// GetInvoicePDF handler for get request over the printable representation of an invoice
+func (m *Repository) GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	inv, ok := m.stampedInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s-%d.pdf\"", inv.Series, inv.Folio))
+	w.WriteHeader(http.StatusOK)
+	w.Write(m.invoices.PDF(inv))
+}
+
+// stampedInvoice fetches the stamped invoice whose id is in the url, writing the error response when it can not
+func (m *Repository) stampedInvoice(w http.ResponseWriter, r *http.Request) (models.Invoice, bool) {
+	invoiceId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return models.Invoice{}, false
+	}
+
+	inv, err := m.db.GetInvoice(invoiceId)
+	if handledInvoiceError(w, err) {
+		return inv, false
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return inv, false
+	}
+
+	if inv.Status != models.InvoiceStamped {
+		resp := helpers.Response{Message: "La factura aún no ha sido timbrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return inv, false
+	}
+
+	return inv, true
+}
+
+// handledInvoiceError writes the response for errors caused by sales that can not be invoiced or missing invoices.
+//
+// It returns false when the error is not related to invoices, so the caller can keep handling it.
+func handledInvoiceError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrInvoiceNotFound) {
+		resp := helpers.Response{Message: "Factura no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSaleNotFound) {
+		resp := helpers.Response{Message: "Venta no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrClientWithoutTaxData) {
+		resp := helpers.Response{Message: "El cliente de la venta no tiene datos fiscales registrados", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSaleHasReturns) {
+		resp := helpers.Response{Message: "La venta tiene devoluciones, no puede facturarse", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrSaleInvoiced) || errors.Is(err, repository.ErrInvoiceStamped) {
+		resp := helpers.Response{Message: "La venta ya fue facturada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
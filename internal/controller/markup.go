@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetMarkupRules handler for get request over markup rule resource
+func (m *Repository) GetMarkupRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := m.db.GetAllMarkupRules()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["rules"] = rules
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostMarkupRule handler for post request over markup rule resource
+func (m *Repository) PostMarkupRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.MarkupRuleDTO
+
+	err := json.NewDecoder(r.Body).Decode(&rule)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidMarkupRule(rule)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	ruleId, err := m.db.InsertMarkupRule(rule)
+	if handledMarkupRuleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Regla de margen creada"
+	data["rule_id"] = ruleId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutMarkupRule handler for put request over markup rule resource
+func (m *Repository) PutMarkupRule(w http.ResponseWriter, r *http.Request) {
+	ruleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var rule models.MarkupRuleDTO
+	err = json.NewDecoder(r.Body).Decode(&rule)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidMarkupRule(rule)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateMarkupRule(ruleId, rule)
+	if handledMarkupRuleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Regla de margen no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Regla de margen actualizada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// DeleteMarkupRule handler for delete request over markup rule resource
+func (m *Repository) DeleteMarkupRule(w http.ResponseWriter, r *http.Request) {
+	ruleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.DeleteMarkupRule(ruleId)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Regla de margen no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Regla de margen eliminada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetPriceSuggestion handler for get request over the public price the markup rules suggest for a product of a
+// category, brand and provider bought at a cost
+func (m *Repository) GetPriceSuggestion(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cost, err := strconv.ParseFloat(query.Get("cost"), 32)
+	if err != nil || cost < 0 {
+		resp := helpers.Response{Message: "Se debe indicar un costo válido", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var categoryId, providerId int
+	if value := query.Get("category_id"); value != "" {
+		categoryId, err = strconv.Atoi(value)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	if value := query.Get("provider_id"); value != "" {
+		providerId, err = strconv.Atoi(value)
+		if err != nil {
+			fmt.Println(err)
+			resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	suggestion, err := m.db.GetPriceSuggestion(categoryId, query.Get("brand"), providerId, float32(cost))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["suggestion"] = suggestion
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// applyMarkupRule gives a product sent without public price the one its markup rule suggests, and checks its
+// public price against the minimum margin of the rule.
+//
+// It returns the warning for a price under the margin of a rule that does not block it, or false when it already
+// wrote the response because the price can not be saved.
+func (m *Repository) applyMarkupRule(w http.ResponseWriter, product *models.ProductDTO) (string, bool) {
+	suggestion, err := m.db.GetPriceSuggestion(product.CategoryID, product.Brand, product.ProviderID, product.ProviderPrice)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return "", false
+	}
+
+	if suggestion.Rule == nil {
+		return "", true
+	}
+
+	if product.PublicPrice == 0 {
+		product.PublicPrice = suggestion.Suggested
+	}
+
+	if product.PublicPrice >= suggestion.MinimumPrice {
+		return "", true
+	}
+
+	message := marginWarning(*suggestion.Rule, suggestion.MinimumPrice)
+	if suggestion.Rule.Block {
+		resp := helpers.Response{Message: message, Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return "", false
+	}
+
+	return message, true
+}
+
+// marginWarning describes a public price left under the minimum price of a markup rule
+func marginWarning(rule models.MarkupRule, minimum float32) string {
+	return fmt.Sprintf("El precio público deja un margen menor al mínimo de %v%%, el precio mínimo es %.2f", rule.MinMargin, minimum)
+}
+
+// handledMarkupRuleError writes the response for errors caused by missing categories or providers and by scopes
+// that already have a rule.
+//
+// It returns false when the error is not related to markup rules, so the caller can keep handling it.
+func handledMarkupRuleError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		resp := helpers.Response{Message: "Categoría no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrProviderNotFound) {
+		resp := helpers.Response{Message: "Proveedor no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrMarkupRuleExists) {
+		resp := helpers.Response{Message: "Ya existe una regla de margen para esa categoría, marca o proveedor", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
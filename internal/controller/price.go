@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// PostPriceUpdate handler for post request that changes the price of many products at once, or previews the
+// change when it is a dry run. Lines left under the minimum margin of their markup rule carry a warning
+func (m *Repository) PostPriceUpdate(w http.ResponseWriter, r *http.Request) {
+	var update models.PriceUpdateDTO
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidPriceUpdate(update)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	result, err := m.db.UpdatePrices(update)
+	for i, line := range result.Lines {
+		if line.Rule != nil {
+			result.Lines[i].Warning = marginWarning(*line.Rule, line.MinimumPrice)
+		}
+	}
+
+	if errors.Is(err, repository.ErrNegativePrice) {
+		resp := helpers.Response{Message: "El cambio deja productos con precio en cero o negativo", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrBelowMinimumMargin) {
+		data := make(map[string]interface{})
+		data["message"] = "El cambio deja productos por debajo del margen mínimo de su regla"
+		data["update"] = result
+		data["error"] = true
+		helpers.WriteJsonResponse(w, http.StatusConflict, data)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["update"] = result
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// GetProductPriceHistory handler for get request over the changes of the public and provider prices of a product
+func (m *Repository) GetProductPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	changes, err := m.db.GetProductPriceHistory(productId)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["history"] = changes
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetPurchaseOrders handler for get request over purchase order resource
+func (m *Repository) GetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := m.db.GetAllPurchaseOrders()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["purchase_orders"] = orders
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// GetPurchaseOrder handler for get request over a single purchase order
+func (m *Repository) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	order, err := m.db.GetPurchaseOrder(orderId)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["purchase_order"] = order
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostPurchaseOrder handler for post request over purchase order resource, the order is created as a draft
+func (m *Repository) PostPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var order models.PurchaseOrderDTO
+
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidPurchaseOrder(order)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	orderId, err := m.db.InsertPurchaseOrder(order)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Orden de compra registrada exitosamente"
+	data["order_id"] = orderId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutPurchaseOrder handler for put request over purchase order resource, only drafts can be edited
+func (m *Repository) PutPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var order models.PurchaseOrderDTO
+	err = json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidPurchaseOrder(order)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.UpdatePurchaseOrder(orderId, order)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Orden de compra actualizada exitosamente", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// PostSendPurchaseOrder handler for post request that marks a draft purchase order as sent to its provider
+func (m *Repository) PostSendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.SendPurchaseOrder(orderId)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Orden de compra enviada al proveedor", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// PostCancelPurchaseOrder handler for post request that cancels a purchase order, the order is kept
+func (m *Repository) PostCancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.CancelPurchaseOrder(orderId)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Orden de compra cancelada", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetPurchaseOrderReceipts handler for get request over the receipts of a purchase order
+func (m *Repository) GetPurchaseOrderReceipts(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receipts, err := m.db.GetPurchaseOrderReceipts(orderId)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["receipts"] = receipts
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostPurchaseOrderReceipt handler for post request that registers a shipment received against a purchase order
+func (m *Repository) PostPurchaseOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var receipt models.GoodsReceiptDTO
+	err = json.NewDecoder(r.Body).Decode(&receipt)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidGoodsReceipt(receipt)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receiptId, err := m.db.ReceivePurchaseOrder(orderId, receipt)
+	if handledPurchaseOrderError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Recepción registrada exitosamente"
+	data["receipt_id"] = receiptId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// handledPurchaseOrderError writes the response for errors caused by missing purchase orders, providers, branches or
+// products, by operations not allowed in the status of a purchase order and by receipts that do not fit the order.
+//
+// It returns false when the error is not related to purchase orders, so the caller can keep handling it.
+func handledPurchaseOrderError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrPurchaseOrderNotFound) {
+		resp := helpers.Response{Message: "Orden de compra no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrProviderNotFound) {
+		resp := helpers.Response{Message: "Proveedor no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrBranchNotFound) {
+		resp := helpers.Response{Message: "Sucursal no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrPurchaseLineNotFound) {
+		resp := helpers.Response{Message: "El producto no forma parte de la orden de compra", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrReceiptExceedsOrder) {
+		resp := helpers.Response{Message: "Se recibieron más unidades de las pendientes en la orden de compra", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrPurchaseOrderStatus) {
+		resp := helpers.Response{Message: "La operación no está permitida en el estado actual de la orden de compra", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+var quoteTemplate = template.Must(template.New("quote").Funcs(template.FuncMap{
+	"money": func(amount float32) string { return fmt.Sprintf("$%.2f", amount) },
+	"date":  func(t time.Time) string { return t.Format("02/01/2006") },
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+	<meta charset="utf-8">
+	<title>Cotización {{.QuoteID}}</title>
+	<style>
+		body { font-family: sans-serif; font-size: 12px; margin: 24px; }
+		table { width: 100%; border-collapse: collapse; margin-top: 16px; }
+		th, td { border-bottom: 1px solid #ccc; padding: 4px; text-align: left; }
+		.amount { text-align: right; }
+	</style>
+</head>
+<body>
+	<h1>Cotización #{{.QuoteID}}</h1>
+	<p>Fecha: {{date .Date}} &mdash; Válida hasta: {{date .ValidUntil}}</p>
+	<p>Cliente: {{.Client.Name}}<br>{{.Client.Address}}<br>Tel. {{.Client.Phone}}</p>
+	<table>
+		<thead>
+			<tr><th>Cantidad</th><th>Producto</th><th>Marca</th><th class="amount">Precio</th><th class="amount">Descuento</th></tr>
+		</thead>
+		<tbody>
+		{{range .Lines}}
+			<tr>
+				<td>{{.Amount}}</td>
+				<td>{{.Product.Classification}}</td>
+				<td>{{.Product.Brand}}</td>
+				<td class="amount">{{money .UnitPrice}}</td>
+				<td class="amount">{{money .Discount}}</td>
+			</tr>
+		{{end}}
+		</tbody>
+	</table>
+	<p class="amount">Subtotal: {{money .SubTotal}}<br>IVA: {{money .Tax}}<br><strong>Total: {{money .Total}}</strong></p>
+	<p>Precios y existencias sujetos a cambio sin previo aviso.</p>
+</body>
+</html>
+`))
+
+// GetQuotes handler for get request over quote resource
+func (m *Repository) GetQuotes(w http.ResponseWriter, r *http.Request) {
+	quotes, err := m.db.GetAllQuotes()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+	data := make(map[string]interface{})
+	data["quotes"] = quotes
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostQuote handler for post request over quote resource
+func (m *Repository) PostQuote(w http.ResponseWriter, r *http.Request) {
+	var quote models.QuoteDTO
+
+	err := json.NewDecoder(r.Body).Decode(&quote)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(quote)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidQuote(quote)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	quoteId, err := m.db.InsertQuote(quote)
+	if handledStockError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Cotización registrada exitosamente"
+	data["quote_id"] = quoteId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// DeleteQuote handler for delete request over quote resource, the quote is cancelled and kept
+func (m *Repository) DeleteQuote(w http.ResponseWriter, r *http.Request) {
+	quoteId, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.CancelQuote(quoteId)
+	if handledQuoteError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Cotización cancelada", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetQuotePrint handler that renders a quote as a printable html page
+func (m *Repository) GetQuotePrint(w http.ResponseWriter, r *http.Request) {
+	quoteId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	quote, err := m.db.GetQuote(quoteId)
+	if handledQuoteError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = quoteTemplate.Execute(w, quote)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// PostConvertQuote handler for post request that turns a quote into a sale
+func (m *Repository) PostConvertQuote(w http.ResponseWriter, r *http.Request) {
+	quoteId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var conversion models.ConvertQuoteDTO
+	err = json.NewDecoder(r.Body).Decode(&conversion)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidPayments(conversion.Payments)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	receipt, err := m.db.ConvertQuote(quoteId, conversion)
+	if handledQuoteError(w, err) || handledSessionError(w, err) || handledStockError(w, err) || handledPaymentError(w, err) ||
+		handledCreditError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Cotización convertida en venta"
+	data["receipt"] = receipt
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// handledQuoteError writes the response for errors caused by missing quotes or quotes that are no longer open.
+//
+// It returns false when the error is not related to quotes, so the caller can keep handling it.
+func handledQuoteError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrQuoteNotFound) {
+		resp := helpers.Response{Message: "Cotización no encontrada", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrQuoteNotOpen) {
+		resp := helpers.Response{Message: "La cotización está vencida, cancelada o ya fue convertida", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetRegisterSessions handler for get request over register resource
+func (m *Repository) GetRegisterSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := m.db.GetAllRegisterSessions()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+	data := make(map[string]interface{})
+	data["sessions"] = sessions
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// GetRegisterSession handler for get request over a single register session
+func (m *Repository) GetRegisterSession(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	session, err := m.db.GetRegisterSession(sessionId)
+	if handledSessionError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+	data := make(map[string]interface{})
+	data["session"] = session
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostOpenRegister handler for post request that opens a register session
+func (m *Repository) PostOpenRegister(w http.ResponseWriter, r *http.Request) {
+	var session models.OpenRegisterDTO
+
+	err := json.NewDecoder(r.Body).Decode(&session)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(session)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	if session.OpeningFloat < 0 {
+		resp := helpers.Response{Message: "El fondo inicial no puede ser negativo", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	sessionId, err := m.db.OpenRegisterSession(session)
+	if errors.Is(err, repository.ErrSessionAlreadyOpen) {
+		resp := helpers.Response{Message: "El cajero ya tiene una caja abierta", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if handledBranchError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Caja abierta"
+	data["session_id"] = sessionId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PostCashMovement handler for post request that puts money into or takes money out of a register
+func (m *Repository) PostCashMovement(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var movement models.CashMovementDTO
+	err = json.NewDecoder(r.Body).Decode(&movement)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(movement)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidCashMovement(movement)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.InsertCashMovement(sessionId, movement)
+	if handledSessionError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Movimiento registrado exitosamente", Error: false}
+	helpers.WriteJsonResponse(w, http.StatusCreated, resp)
+}
+
+// PostCloseRegister handler for post request that closes a register session with the counted money
+func (m *Repository) PostCloseRegister(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var closing models.CloseRegisterDTO
+	err = json.NewDecoder(r.Body).Decode(&closing)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	for _, counted := range closing.Counted {
+		if counted < 0 {
+			resp := helpers.Response{Message: "Las cantidades contadas no pueden ser negativas", Error: true}
+			helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	session, err := m.db.CloseRegisterSession(sessionId, closing)
+	if handledSessionError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Caja cerrada"
+	data["session"] = session
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
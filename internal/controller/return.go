@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+)
+
+// GetReturns handler for get request over return resource
+func (m *Repository) GetReturns(w http.ResponseWriter, r *http.Request) {
+	returns, err := m.db.GetAllReturns()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+	data := make(map[string]interface{})
+	data["returns"] = returns
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostReturn handler for post request over return resource
+func (m *Repository) PostReturn(w http.ResponseWriter, r *http.Request) {
+	var ret models.ReturnDTO
+
+	err := json.NewDecoder(r.Body).Decode(&ret)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	hasEmptyField := validator.HasEmptyStringField(ret)
+	if hasEmptyField {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidReturn(ret)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	returnId, err := m.db.InsertReturn(ret)
+	if handledSessionError(w, err) {
+		return
+	}
+	if errors.Is(err, repository.ErrSaleNotFound) {
+		resp := helpers.Response{Message: "La venta o el producto a devolver no existen", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+	if errors.Is(err, repository.ErrReturnExceedsSale) {
+		resp := helpers.Response{Message: "No se pueden devolver más piezas de las vendidas", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Devolución registrada exitosamente"
+	data["return_id"] = returnId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
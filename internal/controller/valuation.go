@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+)
+
+// GetInventoryValuation handler for get request over the worth of the inventory at the end of a date, today when
+// no date is sent, by the given costing method or the configured one
+func (m *Repository) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	date, err := optionalDate(r.URL.Query().Get("date"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	if date == nil {
+		today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+		date = &today
+	}
+
+	method := r.URL.Query().Get("method")
+	isValid, resp := validator.IsValidCostingMethod(method)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	valuation, err := m.db.GetInventoryValuation(*date, method)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["valuation"] = valuation
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
@@ -0,0 +1,397 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxFitmentImportSize largest CSV accepted by a fitment import, in bytes
+const maxFitmentImportSize = 10 << 20
+
+// GetVehicles handler for get request over vehicle resource
+func (m *Repository) GetVehicles(w http.ResponseWriter, r *http.Request) {
+	vehicles, err := m.db.GetAllVehicles()
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["vehicles"] = vehicles
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PostVehicle handler for post request over vehicle resource
+func (m *Repository) PostVehicle(w http.ResponseWriter, r *http.Request) {
+	var vehicle models.VehicleDTO
+
+	err := json.NewDecoder(r.Body).Decode(&vehicle)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidVehicle(vehicle)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	vehicleId, err := m.db.InsertVehicle(vehicle)
+	if handledVehicleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["message"] = "Vehículo agregado al catálogo"
+	data["vehicle_id"] = vehicleId
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusCreated, data)
+}
+
+// PutVehicle handler for put request over vehicle resource
+func (m *Repository) PutVehicle(w http.ResponseWriter, r *http.Request) {
+	vehicleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var vehicle models.VehicleDTO
+	err = json.NewDecoder(r.Body).Decode(&vehicle)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidVehicle(vehicle)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.UpdateVehicle(vehicleId, vehicle)
+	if handledVehicleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Vehículo no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Vehículo actualizado"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// DeleteVehicle handler for delete request over vehicle resource, the fitments of the vehicle are removed too
+func (m *Repository) DeleteVehicle(w http.ResponseWriter, r *http.Request) {
+	vehicleId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.DeleteVehicle(vehicleId)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "Vehículo no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Vehículo eliminado del catálogo"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// GetProductFitment handler for get request that lists the vehicles a product fits
+func (m *Repository) GetProductFitment(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	fitments, err := m.db.GetProductFitment(productId)
+	if handledVehicleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["fitment"] = fitments
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// PutProductFitment handler for put request that records a vehicle a product fits
+func (m *Repository) PutProductFitment(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	var fitment models.FitmentDTO
+	err = json.NewDecoder(r.Body).Decode(&fitment)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	isValid, resp := validator.IsValidFitment(fitment)
+	if !isValid {
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	err = m.db.SetProductFitment(productId, fitment)
+	if handledVehicleError(w, err) {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp = helpers.Response{Message: "Aplicación del producto guardada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// DeleteProductFitment handler for delete request that records a product no longer fits a vehicle
+func (m *Repository) DeleteProductFitment(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	vehicleId, err := strconv.Atoi(chi.URLParam(r, "vehicleId"))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "La información se envió en un formato incorrecto", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	rows, err := m.db.DeleteProductFitment(productId, vehicleId)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	if rows == 0 {
+		resp := helpers.Response{Message: "El producto no tiene registrada esa aplicación", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return
+	}
+
+	resp := helpers.Response{Message: "Aplicación del producto eliminada"}
+	helpers.WriteJsonResponse(w, http.StatusOK, resp)
+}
+
+// PostFitmentImport handler for post request that imports fitments in bulk from a CSV sent as the request body.
+//
+// The first line names the columns: code, make, model and year_from are required; year_to, engine, trim and
+// notes are optional. Invalid rows are skipped and reported with their line number.
+func (m *Repository) PostFitmentImport(w http.ResponseWriter, r *http.Request) {
+	rows, rowErrors, err := readFitmentCSV(http.MaxBytesReader(w, r.Body, maxFitmentImportSize))
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "El archivo CSV no es válido: " + err.Error(), Error: true}
+		helpers.WriteJsonResponse(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	result, err := m.db.ImportFitment(rows)
+	if err != nil {
+		fmt.Println(err)
+		resp := helpers.Response{Message: "Algo salió mal...", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	result.Errors = append(result.Errors, rowErrors...)
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Line < result.Errors[j].Line
+	})
+
+	data := make(map[string]interface{})
+	data["message"] = "Aplicaciones importadas"
+	data["import"] = result
+	data["error"] = false
+	helpers.WriteJsonResponse(w, http.StatusOK, data)
+}
+
+// readFitmentCSV reads the rows of a fitment import, the ones that are not valid are returned as errors instead.
+// It fails when the file itself can not be read or lacks a required column
+func readFitmentCSV(body io.Reader) ([]models.FitmentRowDTO, []models.FitmentImportError, error) {
+	// Spreadsheets save UTF-8 files with a byte order mark that would stick to the name of the first column
+	buffered := bufio.NewReader(body)
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+		buffered.Discard(3)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"code", "make", "model", "year_from"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, errors.New("falta la columna " + name)
+		}
+	}
+
+	rows := []models.FitmentRowDTO{}
+	rowErrors := []models.FitmentImportError{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := models.FitmentRowDTO{
+			Line:  line,
+			Code:  field("code"),
+			Notes: field("notes"),
+			Vehicle: models.VehicleDTO{
+				Make:   field("make"),
+				Model:  field("model"),
+				Engine: field("engine"),
+				Trim:   field("trim"),
+			},
+		}
+
+		row.Vehicle.YearFrom, err = strconv.Atoi(field("year_from"))
+		if err != nil {
+			rowErrors = append(rowErrors, models.FitmentImportError{Line: line, Message: "Año inicial no válido"})
+			continue
+		}
+
+		if yearTo := field("year_to"); yearTo != "" {
+			row.Vehicle.YearTo, err = strconv.Atoi(yearTo)
+			if err != nil {
+				rowErrors = append(rowErrors, models.FitmentImportError{Line: line, Message: "Año final no válido"})
+				continue
+			}
+		}
+
+		isValid, resp := validator.IsValidFitmentRow(row)
+		if !isValid {
+			rowErrors = append(rowErrors, models.FitmentImportError{Line: line, Message: resp.Message})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// handledVehicleError writes the response for errors caused by missing products or vehicles and by vehicles that
+// are already in the catalog.
+//
+// It returns false when the error is not related to vehicles, so the caller can keep handling it.
+func handledVehicleError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, repository.ErrProductNotFound) {
+		resp := helpers.Response{Message: "Producto no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrVehicleNotFound) {
+		resp := helpers.Response{Message: "Vehículo no encontrado", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusNotFound, resp)
+		return true
+	}
+
+	if errors.Is(err, repository.ErrVehicleExists) {
+		resp := helpers.Response{Message: "El vehículo ya está en el catálogo", Error: true}
+		helpers.WriteJsonResponse(w, http.StatusConflict, resp)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,295 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+const (
+	cfdiNamespace      = "http://www.sat.gob.mx/cfd/4"
+	tfdNamespace       = "http://www.sat.gob.mx/TimbreFiscalDigital"
+	xsiNamespace       = "http://www.w3.org/2001/XMLSchema-instance"
+	cfdiSchemaLocation = "http://www.sat.gob.mx/cfd/4 http://www.sat.gob.mx/sitio_internet/cfd/4/cfdv40.xsd"
+	tfdSchemaLocation  = "http://www.sat.gob.mx/TimbreFiscalDigital http://www.sat.gob.mx/sitio_internet/cfd/TimbreFiscalDigital/TimbreFiscalDigitalv11.xsd"
+
+	// dateLayout layout of dates inside a CFDI, always in the local time of the issuer
+	dateLayout = "2006-01-02T15:04:05"
+)
+
+// Values of the SAT catalogs used by the invoices of the store
+const (
+	IncomeVoucher     = "I"
+	CurrencyMXN       = "MXN"
+	NoExport          = "01"
+	SingleExhibition  = "PUE"
+	DeferredPayment   = "PPD"
+	ToBeDefined       = "99"
+	TaxableObject     = "02"
+	IVATax            = "002"
+	RateFactor        = "Tasa"
+	PieceUnitKey      = "H87"
+	PieceUnitName     = "Pieza"
+	DefaultProductKey = "01010101"
+)
+
+// paymentForms maps the payment methods of a sale to the SAT catalog of payment forms
+var paymentForms = map[string]string{
+	models.PaymentCash:        "01",
+	models.PaymentTransfer:    "03",
+	models.PaymentCard:        "04",
+	models.PaymentStoreCredit: "30",
+}
+
+// Money amount in cents, written with two decimals inside the XML
+type Money int64
+
+// NewMoney converts an amount of the database to cents
+func NewMoney(amount float32) Money {
+	return Money(math.Round(float64(amount) * 100))
+}
+
+// String returns the amount with two decimals
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// MarshalXMLAttr writes the amount with two decimals
+func (m Money) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: m.String()}, nil
+}
+
+// Comprobante root node of a CFDI 4.0
+type Comprobante struct {
+	XMLName           xml.Name     `xml:"cfdi:Comprobante"`
+	XmlnsCfdi         string       `xml:"xmlns:cfdi,attr"`
+	XmlnsXsi          string       `xml:"xmlns:xsi,attr"`
+	SchemaLocation    string       `xml:"xsi:schemaLocation,attr"`
+	Version           string       `xml:"Version,attr"`
+	Serie             string       `xml:"Serie,attr,omitempty"`
+	Folio             string       `xml:"Folio,attr,omitempty"`
+	Fecha             string       `xml:"Fecha,attr"`
+	Sello             string       `xml:"Sello,attr"`
+	FormaPago         string       `xml:"FormaPago,attr,omitempty"`
+	NoCertificado     string       `xml:"NoCertificado,attr"`
+	Certificado       string       `xml:"Certificado,attr"`
+	SubTotal          Money        `xml:"SubTotal,attr"`
+	Descuento         Money        `xml:"Descuento,attr,omitempty"`
+	Moneda            string       `xml:"Moneda,attr"`
+	Total             Money        `xml:"Total,attr"`
+	TipoDeComprobante string       `xml:"TipoDeComprobante,attr"`
+	Exportacion       string       `xml:"Exportacion,attr"`
+	MetodoPago        string       `xml:"MetodoPago,attr,omitempty"`
+	LugarExpedicion   string       `xml:"LugarExpedicion,attr"`
+	Emisor            Emisor       `xml:"cfdi:Emisor"`
+	Receptor          Receptor     `xml:"cfdi:Receptor"`
+	Conceptos         []Concepto   `xml:"cfdi:Conceptos>cfdi:Concepto"`
+	Impuestos         *Impuestos   `xml:"cfdi:Impuestos,omitempty"`
+	Complemento       *Complemento `xml:"cfdi:Complemento,omitempty"`
+}
+
+// Emisor issuer of a CFDI
+type Emisor struct {
+	Rfc           string `xml:"Rfc,attr"`
+	Nombre        string `xml:"Nombre,attr"`
+	RegimenFiscal string `xml:"RegimenFiscal,attr"`
+}
+
+// Receptor receiver of a CFDI
+type Receptor struct {
+	Rfc                     string `xml:"Rfc,attr"`
+	Nombre                  string `xml:"Nombre,attr"`
+	DomicilioFiscalReceptor string `xml:"DomicilioFiscalReceptor,attr"`
+	RegimenFiscalReceptor   string `xml:"RegimenFiscalReceptor,attr"`
+	UsoCFDI                 string `xml:"UsoCFDI,attr"`
+}
+
+// Concepto line of a CFDI
+type Concepto struct {
+	ClaveProdServ    string             `xml:"ClaveProdServ,attr"`
+	NoIdentificacion string             `xml:"NoIdentificacion,attr,omitempty"`
+	Cantidad         int                `xml:"Cantidad,attr"`
+	ClaveUnidad      string             `xml:"ClaveUnidad,attr"`
+	Unidad           string             `xml:"Unidad,attr,omitempty"`
+	Descripcion      string             `xml:"Descripcion,attr"`
+	ValorUnitario    Money              `xml:"ValorUnitario,attr"`
+	Importe          Money              `xml:"Importe,attr"`
+	Descuento        Money              `xml:"Descuento,attr,omitempty"`
+	ObjetoImp        string             `xml:"ObjetoImp,attr"`
+	Impuestos        *ImpuestosConcepto `xml:"cfdi:Impuestos,omitempty"`
+}
+
+// ImpuestosConcepto taxes of a line of a CFDI
+type ImpuestosConcepto struct {
+	Traslados []Traslado `xml:"cfdi:Traslados>cfdi:Traslado"`
+}
+
+// Impuestos summary of the taxes of a CFDI
+type Impuestos struct {
+	TotalImpuestosTrasladados Money      `xml:"TotalImpuestosTrasladados,attr"`
+	Traslados                 []Traslado `xml:"cfdi:Traslados>cfdi:Traslado"`
+}
+
+// Traslado tax transferred to the receiver, IVA for every invoice of the store
+type Traslado struct {
+	Base       Money  `xml:"Base,attr"`
+	Impuesto   string `xml:"Impuesto,attr"`
+	TipoFactor string `xml:"TipoFactor,attr"`
+	TasaOCuota string `xml:"TasaOCuota,attr"`
+	Importe    Money  `xml:"Importe,attr"`
+}
+
+// Complemento holds the stamp given by the PAC
+type Complemento struct {
+	TimbreFiscalDigital TimbreFiscalDigital `xml:"tfd:TimbreFiscalDigital"`
+}
+
+// TimbreFiscalDigital stamp of a CFDI, its UUID is the fiscal folio of the invoice
+type TimbreFiscalDigital struct {
+	XmlnsTfd         string `xml:"xmlns:tfd,attr"`
+	SchemaLocation   string `xml:"xsi:schemaLocation,attr"`
+	Version          string `xml:"Version,attr"`
+	UUID             string `xml:"UUID,attr"`
+	FechaTimbrado    string `xml:"FechaTimbrado,attr"`
+	RfcProvCertif    string `xml:"RfcProvCertif,attr"`
+	SelloCFD         string `xml:"SelloCFD,attr"`
+	NoCertificadoSAT string `xml:"NoCertificadoSAT,attr"`
+	SelloSAT         string `xml:"SelloSAT,attr"`
+}
+
+// Build builds the CFDI of an invoice issued by issuer.
+//
+// The IVA of every line is rounded on its own and the totals are the sum of the lines, as the SAT requires,
+// so the invoice may differ by cents from a sale whose tax was computed over its subtotal.
+func Build(issuer Issuer, inv models.Invoice) Comprobante {
+	method, form := inv.PaymentMethod, inv.PaymentForm
+	if method == "" {
+		method, form = PaymentTerms(inv.SalePaid, inv.SaleTender)
+	}
+
+	rate := math.Round(float64(inv.TaxRate)*1e6) / 1e6
+	c := Comprobante{
+		XmlnsCfdi:         cfdiNamespace,
+		XmlnsXsi:          xsiNamespace,
+		SchemaLocation:    cfdiSchemaLocation,
+		Version:           "4.0",
+		Serie:             inv.Series,
+		Folio:             strconv.Itoa(inv.Folio),
+		Fecha:             inv.Date.Format(dateLayout),
+		FormaPago:         form,
+		Moneda:            CurrencyMXN,
+		TipoDeComprobante: IncomeVoucher,
+		Exportacion:       NoExport,
+		MetodoPago:        method,
+		LugarExpedicion:   issuer.ZipCode,
+		Emisor: Emisor{
+			Rfc:           issuer.RFC,
+			Nombre:        issuer.Name,
+			RegimenFiscal: issuer.TaxRegime,
+		},
+		Receptor: Receptor{
+			Rfc:                     inv.Receiver.RFC,
+			Nombre:                  inv.Receiver.LegalName,
+			DomicilioFiscalReceptor: inv.Receiver.ZipCode,
+			RegimenFiscalReceptor:   inv.Receiver.TaxRegime,
+			UsoCFDI:                 inv.Receiver.CfdiUse,
+		},
+		Conceptos: []Concepto{},
+	}
+
+	var base, tax Money
+	for _, line := range inv.Lines {
+		unitPrice := NewMoney(line.UnitPrice)
+		amount := unitPrice * Money(line.Amount)
+		discount := NewMoney(line.Discount)
+		transfer := Traslado{
+			Base:       amount - discount,
+			Impuesto:   IVATax,
+			TipoFactor: RateFactor,
+			TasaOCuota: fmt.Sprintf("%.6f", rate),
+			Importe:    Money(math.Round(float64(amount-discount) * rate)),
+		}
+
+		key := line.SATKey
+		if key == "" {
+			key = DefaultProductKey
+		}
+
+		c.Conceptos = append(c.Conceptos, Concepto{
+			ClaveProdServ:    key,
+			NoIdentificacion: strconv.Itoa(line.ProductID),
+			Cantidad:         line.Amount,
+			ClaveUnidad:      PieceUnitKey,
+			Unidad:           PieceUnitName,
+			Descripcion:      line.Description,
+			ValorUnitario:    unitPrice,
+			Importe:          amount,
+			Descuento:        discount,
+			ObjetoImp:        TaxableObject,
+			Impuestos:        &ImpuestosConcepto{Traslados: []Traslado{transfer}},
+		})
+
+		c.SubTotal += amount
+		c.Descuento += discount
+		base += transfer.Base
+		tax += transfer.Importe
+	}
+
+	c.Impuestos = &Impuestos{
+		TotalImpuestosTrasladados: tax,
+		Traslados: []Traslado{{
+			Base:       base,
+			Impuesto:   IVATax,
+			TipoFactor: RateFactor,
+			TasaOCuota: fmt.Sprintf("%.6f", rate),
+			Importe:    tax,
+		}},
+	}
+	c.Total = c.SubTotal - c.Descuento + tax
+
+	if inv.Status == models.InvoiceStamped && inv.StampedAt != nil {
+		c.Complemento = &Complemento{TimbreFiscalDigital: TimbreFiscalDigital{
+			XmlnsTfd:       tfdNamespace,
+			SchemaLocation: tfdSchemaLocation,
+			Version:        "1.1",
+			UUID:           inv.UUID,
+			FechaTimbrado:  inv.StampedAt.Format(dateLayout),
+		}}
+	}
+
+	return c
+}
+
+// PaymentTerms returns the SAT payment method and payment form of a sale.
+//
+// A paid sale is paid in a single exhibition with the method that covered most of it, any other sale is paid in
+// installments and its payment form is defined by the payments to come.
+func PaymentTerms(paid bool, tender string) (method, form string) {
+	if !paid {
+		return DeferredPayment, ToBeDefined
+	}
+
+	form, ok := paymentForms[tender]
+	if !ok {
+		form = ToBeDefined
+	}
+
+	return SingleExhibition, form
+}
+
+// Marshal writes the CFDI as an XML document
+func Marshal(c Comprobante) ([]byte, error) {
+	body, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,270 @@
+package invoice
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+var testIssuer = Issuer{
+	RFC:       "EKU9003173C9",
+	Name:      "REFACCIONARIA DE PRUEBA",
+	TaxRegime: "601",
+	ZipCode:   "45079",
+	Series:    DefaultSeries,
+}
+
+// testInvoice returns a pending invoice of a paid cash sale with the given lines
+func testInvoice(lines ...models.InvoiceLine) models.Invoice {
+	return models.Invoice{
+		Series:     DefaultSeries,
+		Folio:      1,
+		Status:     models.InvoicePending,
+		Date:       time.Date(2026, 10, 18, 12, 30, 0, 0, time.Local),
+		TaxRate:    0.16,
+		SalePaid:   true,
+		SaleTender: models.PaymentCash,
+		Receiver: models.TaxDataDTO{
+			RFC:       "XAXX010101000",
+			LegalName: "PUBLICO EN GENERAL",
+			TaxRegime: "616",
+			ZipCode:   "45079",
+			CfdiUse:   "S01",
+		},
+		Lines: lines,
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{50, "0.50"},
+		{100, "1.00"},
+		{12345, "123.45"},
+		{-5, "-0.05"},
+		{-100, "-1.00"},
+		{-12345, "-123.45"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.money), got, tt.want)
+		}
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	tests := []struct {
+		amount float32
+		want   Money
+	}{
+		{0, 0},
+		{0.01, 1},
+		{10.01, 1001},
+		{99.99, 9999},
+		{19.999, 2000},
+		{-5.55, -555},
+	}
+
+	for _, tt := range tests {
+		if got := NewMoney(tt.amount); got != tt.want {
+			t.Errorf("NewMoney(%v) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestPaymentTerms(t *testing.T) {
+	tests := []struct {
+		name       string
+		paid       bool
+		tender     string
+		wantMethod string
+		wantForm   string
+	}{
+		{"unpaid sale", false, models.PaymentCash, DeferredPayment, ToBeDefined},
+		{"unpaid sale without payments", false, "", DeferredPayment, ToBeDefined},
+		{"paid in cash", true, models.PaymentCash, SingleExhibition, "01"},
+		{"paid by transfer", true, models.PaymentTransfer, SingleExhibition, "03"},
+		{"paid by card", true, models.PaymentCard, SingleExhibition, "04"},
+		{"paid with store credit", true, models.PaymentStoreCredit, SingleExhibition, "30"},
+		{"paid with unknown method", true, "cheque", SingleExhibition, ToBeDefined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, form := PaymentTerms(tt.paid, tt.tender)
+			if method != tt.wantMethod || form != tt.wantForm {
+				t.Errorf("PaymentTerms(%v, %q) = (%q, %q), want (%q, %q)", tt.paid, tt.tender, method, form, tt.wantMethod, tt.wantForm)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []models.InvoiceLine
+		wantLineTax  []Money
+		wantSubTotal Money
+		wantDiscount Money
+		wantTax      Money
+		wantTotal    Money
+	}{
+		{
+			name: "single line",
+			lines: []models.InvoiceLine{
+				{ProductID: 1, SATKey: "25174004", Description: "Balata delantera", Amount: 2, UnitPrice: 450},
+			},
+			wantLineTax:  []Money{14400},
+			wantSubTotal: 90000,
+			wantTax:      14400,
+			wantTotal:    104400,
+		},
+		{
+			name: "tax rounded per line",
+			lines: []models.InvoiceLine{
+				{ProductID: 1, Description: "Rondana", Amount: 3, UnitPrice: 10.01},
+				{ProductID: 2, Description: "Filtro de aceite", Amount: 1, UnitPrice: 99.99, Discount: 5.55},
+			},
+			wantLineTax:  []Money{480, 1511},
+			wantSubTotal: 13002,
+			wantDiscount: 555,
+			wantTax:      1991,
+			wantTotal:    14438,
+		},
+		{
+			// Over the subtotal the tax would be one cent, every line on its own rounds down to zero
+			name: "cents lost on every line",
+			lines: []models.InvoiceLine{
+				{ProductID: 1, Description: "Grapa", Amount: 1, UnitPrice: 0.03},
+				{ProductID: 2, Description: "Grapa", Amount: 1, UnitPrice: 0.03},
+			},
+			wantLineTax:  []Money{0, 0},
+			wantSubTotal: 6,
+			wantTax:      0,
+			wantTotal:    6,
+		},
+		{
+			name: "fully discounted line",
+			lines: []models.InvoiceLine{
+				{ProductID: 1, Description: "Tapón", Amount: 1, UnitPrice: 25, Discount: 25},
+			},
+			wantLineTax:  []Money{0},
+			wantSubTotal: 2500,
+			wantDiscount: 2500,
+			wantTax:      0,
+			wantTotal:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Build(testIssuer, testInvoice(tt.lines...))
+
+			if len(c.Conceptos) != len(tt.lines) {
+				t.Fatalf("got %d conceptos, want %d", len(c.Conceptos), len(tt.lines))
+			}
+			for i, concept := range c.Conceptos {
+				transfer := concept.Impuestos.Traslados[0]
+				if transfer.Importe != tt.wantLineTax[i] {
+					t.Errorf("concepto %d: IVA = %s, want %s", i+1, transfer.Importe, tt.wantLineTax[i])
+				}
+				if transfer.Base != concept.Importe-concept.Descuento {
+					t.Errorf("concepto %d: Base = %s, want %s", i+1, transfer.Base, concept.Importe-concept.Descuento)
+				}
+				if transfer.TasaOCuota != "0.160000" {
+					t.Errorf("concepto %d: TasaOCuota = %q, want %q", i+1, transfer.TasaOCuota, "0.160000")
+				}
+			}
+
+			if c.SubTotal != tt.wantSubTotal {
+				t.Errorf("SubTotal = %s, want %s", c.SubTotal, tt.wantSubTotal)
+			}
+			if c.Descuento != tt.wantDiscount {
+				t.Errorf("Descuento = %s, want %s", c.Descuento, tt.wantDiscount)
+			}
+			if c.Impuestos.TotalImpuestosTrasladados != tt.wantTax {
+				t.Errorf("TotalImpuestosTrasladados = %s, want %s", c.Impuestos.TotalImpuestosTrasladados, tt.wantTax)
+			}
+			if c.Total != tt.wantTotal {
+				t.Errorf("Total = %s, want %s", c.Total, tt.wantTotal)
+			}
+			if err := Validate(c); err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestBuildDefaults(t *testing.T) {
+	inv := testInvoice(models.InvoiceLine{ProductID: 7, Description: "Bujía", Amount: 4, UnitPrice: 85.5})
+	inv.SalePaid = false
+
+	c := Build(testIssuer, inv)
+
+	if c.MetodoPago != DeferredPayment || c.FormaPago != ToBeDefined {
+		t.Errorf("payment terms = (%q, %q), want (%q, %q)", c.MetodoPago, c.FormaPago, DeferredPayment, ToBeDefined)
+	}
+	if c.Conceptos[0].ClaveProdServ != DefaultProductKey {
+		t.Errorf("ClaveProdServ = %q, want %q", c.Conceptos[0].ClaveProdServ, DefaultProductKey)
+	}
+	if c.Conceptos[0].NoIdentificacion != "7" {
+		t.Errorf("NoIdentificacion = %q, want %q", c.Conceptos[0].NoIdentificacion, "7")
+	}
+	if c.Fecha != "2026-10-18T12:30:00" {
+		t.Errorf("Fecha = %q, want %q", c.Fecha, "2026-10-18T12:30:00")
+	}
+	if c.Complemento != nil {
+		t.Errorf("Complemento = %+v, want nil for a pending invoice", c.Complemento)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	inv := testInvoice(
+		models.InvoiceLine{ProductID: 1, Description: "Rondana", Amount: 3, UnitPrice: 10.01},
+		models.InvoiceLine{ProductID: 2, Description: "Filtro de aceite & junta", Amount: 1, UnitPrice: 99.99, Discount: 5.55},
+	)
+
+	document, err := Marshal(Build(testIssuer, inv))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	xml := string(document)
+	if !strings.HasPrefix(xml, `<?xml version="1.0" encoding="UTF-8"?>`) {
+		t.Errorf("document does not start with the XML header:\n%s", xml)
+	}
+
+	want := []string{
+		`<cfdi:Comprobante xmlns:cfdi="http://www.sat.gob.mx/cfd/4"`,
+		`Version="4.0"`,
+		`Folio="1"`,
+		`SubTotal="130.02"`,
+		`Descuento="5.55"`,
+		`Total="144.38"`,
+		`MetodoPago="PUE"`,
+		`FormaPago="01"`,
+		`<cfdi:Emisor Rfc="EKU9003173C9"`,
+		`<cfdi:Receptor Rfc="XAXX010101000"`,
+		`Descripcion="Filtro de aceite &amp; junta"`,
+		`TotalImpuestosTrasladados="19.91"`,
+	}
+	for _, fragment := range want {
+		if !strings.Contains(xml, fragment) {
+			t.Errorf("document does not contain %s:\n%s", fragment, xml)
+		}
+	}
+
+	if strings.Contains(xml, "cfdi:Complemento") {
+		t.Errorf("document of a pending invoice contains a Complemento:\n%s", xml)
+	}
+	if strings.Count(xml, "<cfdi:Concepto ") != 2 {
+		t.Errorf("document does not contain 2 conceptos:\n%s", xml)
+	}
+}
@@ -0,0 +1,101 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// DefaultSeries series used for the folios of the invoices when no other one is configured
+const DefaultSeries = "A"
+
+// Issuer tax data of the store, it issues every invoice
+type Issuer struct {
+	RFC       string
+	Name      string
+	TaxRegime string
+	ZipCode   string
+	Series    string
+}
+
+// Service builds, validates and stamps the invoices of the sales
+type Service struct {
+	issuer  Issuer
+	pac     PAC
+	taxRate float32
+}
+
+// NewService creates an invoice service for issuer that stamps through pac and charges the given IVA rate
+func NewService(issuer Issuer, pac PAC, taxRate float32) Service {
+	if issuer.Series == "" {
+		issuer.Series = DefaultSeries
+	}
+
+	return Service{issuer: issuer, pac: pac, taxRate: taxRate}
+}
+
+// Series returns the series the folios of new invoices are taken from
+func (s Service) Series() string {
+	return s.issuer.Series
+}
+
+// TaxRate returns the IVA rate charged on new invoices
+func (s Service) TaxRate() float32 {
+	return s.taxRate
+}
+
+// Stamp builds the CFDI of a pending invoice dated now, validates it and sends it to the PAC.
+//
+// The returned invoice carries the stamp and the stamped XML, it must be persisted by the caller.
+func (s Service) Stamp(ctx context.Context, inv models.Invoice) (models.Invoice, error) {
+	inv.Date = time.Now()
+	inv.PaymentMethod, inv.PaymentForm = PaymentTerms(inv.SalePaid, inv.SaleTender)
+
+	c := Build(s.issuer, inv)
+	if err := Validate(c); err != nil {
+		return inv, err
+	}
+
+	document, err := Marshal(c)
+	if err != nil {
+		return inv, err
+	}
+
+	stamp, err := s.pac.Stamp(ctx, document)
+	if err != nil {
+		return inv, err
+	}
+
+	c.Sello = stamp.Seal
+	c.NoCertificado = stamp.CertificateNumber
+	c.Certificado = stamp.Certificate
+	c.Complemento = &Complemento{TimbreFiscalDigital: TimbreFiscalDigital{
+		XmlnsTfd:         tfdNamespace,
+		SchemaLocation:   tfdSchemaLocation,
+		Version:          "1.1",
+		UUID:             stamp.UUID,
+		FechaTimbrado:    stamp.StampedAt.Format(dateLayout),
+		RfcProvCertif:    stamp.ProviderRFC,
+		SelloCFD:         stamp.Seal,
+		NoCertificadoSAT: stamp.SATCertificateNumber,
+		SelloSAT:         stamp.SATSeal,
+	}}
+
+	document, err = Marshal(c)
+	if err != nil {
+		return inv, err
+	}
+
+	inv.Status = models.InvoiceStamped
+	inv.UUID = stamp.UUID
+	inv.StampedAt = &stamp.StampedAt
+	inv.XML = string(document)
+
+	return inv, nil
+}
+
+// PDF returns the printable representation of an invoice
+func (s Service) PDF(inv models.Invoice) []byte {
+	return RenderPDF(Build(s.issuer, inv))
+}
@@ -0,0 +1,65 @@
+package invoice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// PAC authorized certification provider that seals a CFDI with the certificate of the issuer and stamps it
+type PAC interface {
+	Stamp(ctx context.Context, cfdi []byte) (Stamp, error)
+}
+
+// Stamp data returned by a PAC once a CFDI is stamped
+type Stamp struct {
+	UUID                 string
+	StampedAt            time.Time
+	ProviderRFC          string
+	Seal                 string
+	CertificateNumber    string
+	Certificate          string
+	SATSeal              string
+	SATCertificateNumber string
+}
+
+// FakePAC stamps invoices locally without reaching the SAT, the invoices it stamps have no fiscal validity
+type FakePAC struct{}
+
+// NewFakePAC creates a PAC that stamps invoices locally
+func NewFakePAC() FakePAC {
+	return FakePAC{}
+}
+
+// Stamp gives the CFDI a random UUID and seals derived from its content
+func (FakePAC) Stamp(ctx context.Context, cfdi []byte) (Stamp, error) {
+	if err := ctx.Err(); err != nil {
+		return Stamp{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Stamp{}, err
+	}
+	// Version 4 UUID as the SAT uses
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	digest := sha256.Sum256(cfdi)
+	seal := base64.StdEncoding.EncodeToString(digest[:])
+	satDigest := sha256.Sum256(append(digest[:], id...))
+
+	return Stamp{
+		UUID:                 fmt.Sprintf("%X-%X-%X-%X-%X", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		StampedAt:            time.Now(),
+		ProviderRFC:          "SPR190613I52",
+		Seal:                 seal,
+		CertificateNumber:    "30001000000500003416",
+		Certificate:          base64.StdEncoding.EncodeToString([]byte("fake certificate")),
+		SATSeal:              base64.StdEncoding.EncodeToString(satDigest[:]),
+		SATCertificateNumber: "30001000000500003456",
+	}, nil
+}
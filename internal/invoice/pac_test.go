@@ -0,0 +1,152 @@
+package invoice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9A-F]{8}-[0-9A-F]{4}-4[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}$`)
+
+func TestFakePACStamp(t *testing.T) {
+	document := []byte("<cfdi:Comprobante/>")
+
+	stamp, err := NewFakePAC().Stamp(context.Background(), document)
+	if err != nil {
+		t.Fatalf("Stamp() error = %v", err)
+	}
+
+	if !uuidPattern.MatchString(stamp.UUID) {
+		t.Errorf("UUID = %q, want an uppercase version 4 UUID", stamp.UUID)
+	}
+	digest := sha256.Sum256(document)
+	if want := base64.StdEncoding.EncodeToString(digest[:]); stamp.Seal != want {
+		t.Errorf("Seal = %q, want %q", stamp.Seal, want)
+	}
+	if !rfcPattern.MatchString(stamp.ProviderRFC) {
+		t.Errorf("ProviderRFC = %q, want a valid RFC", stamp.ProviderRFC)
+	}
+	if !certificatePattern.MatchString(stamp.CertificateNumber) || !certificatePattern.MatchString(stamp.SATCertificateNumber) {
+		t.Errorf("certificate numbers = (%q, %q), want 20 digits", stamp.CertificateNumber, stamp.SATCertificateNumber)
+	}
+	if stamp.StampedAt.IsZero() {
+		t.Error("StampedAt is zero")
+	}
+
+	again, err := NewFakePAC().Stamp(context.Background(), document)
+	if err != nil {
+		t.Fatalf("Stamp() error = %v", err)
+	}
+	if again.UUID == stamp.UUID {
+		t.Errorf("two stamps got the same UUID %q", stamp.UUID)
+	}
+}
+
+func TestFakePACStampCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewFakePAC().Stamp(ctx, []byte("<cfdi:Comprobante/>"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Stamp() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestServiceStamp(t *testing.T) {
+	service := NewService(Issuer{
+		RFC:       testIssuer.RFC,
+		Name:      testIssuer.Name,
+		TaxRegime: testIssuer.TaxRegime,
+		ZipCode:   testIssuer.ZipCode,
+	}, NewFakePAC(), 0.16)
+
+	if service.Series() != DefaultSeries {
+		t.Errorf("Series() = %q, want %q", service.Series(), DefaultSeries)
+	}
+
+	inv := testInvoice(
+		models.InvoiceLine{ProductID: 1, SATKey: "25174004", Description: "Balata delantera", Amount: 2, UnitPrice: 450},
+		models.InvoiceLine{ProductID: 2, Description: "Filtro de aceite", Amount: 1, UnitPrice: 99.99, Discount: 5.55},
+	)
+	inv.SaleTender = models.PaymentCard
+
+	stamped, err := service.Stamp(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("Stamp() error = %v", err)
+	}
+
+	if stamped.Status != models.InvoiceStamped {
+		t.Errorf("Status = %q, want %q", stamped.Status, models.InvoiceStamped)
+	}
+	if !uuidPattern.MatchString(stamped.UUID) {
+		t.Errorf("UUID = %q, want an uppercase version 4 UUID", stamped.UUID)
+	}
+	if stamped.StampedAt == nil {
+		t.Fatal("StampedAt is nil")
+	}
+	if stamped.PaymentMethod != SingleExhibition || stamped.PaymentForm != "04" {
+		t.Errorf("payment terms = (%q, %q), want (%q, %q)", stamped.PaymentMethod, stamped.PaymentForm, SingleExhibition, "04")
+	}
+
+	var document struct {
+		Sello         string `xml:"Sello,attr"`
+		NoCertificado string `xml:"NoCertificado,attr"`
+		Total         string `xml:"Total,attr"`
+		Timbre        struct {
+			UUID     string `xml:"UUID,attr"`
+			SelloCFD string `xml:"SelloCFD,attr"`
+		} `xml:"Complemento>TimbreFiscalDigital"`
+	}
+	if err := xml.Unmarshal([]byte(stamped.XML), &document); err != nil {
+		t.Fatalf("stamped XML does not parse: %v", err)
+	}
+
+	if document.Timbre.UUID != stamped.UUID {
+		t.Errorf("UUID in the XML = %q, want %q", document.Timbre.UUID, stamped.UUID)
+	}
+	if document.Sello == "" || document.Sello != document.Timbre.SelloCFD {
+		t.Errorf("Sello = %q and SelloCFD = %q, want the same seal", document.Sello, document.Timbre.SelloCFD)
+	}
+	if document.NoCertificado == "" {
+		t.Error("NoCertificado is empty")
+	}
+	if document.Total != "1153.55" {
+		t.Errorf("Total = %q, want %q", document.Total, "1153.55")
+	}
+
+	// The stamped invoice builds back into a valid document that carries its stamp
+	rebuilt := Build(testIssuer, stamped)
+	if err := Validate(rebuilt); err != nil {
+		t.Errorf("Validate() of the stamped invoice = %v, want nil", err)
+	}
+	if rebuilt.Complemento == nil || rebuilt.Complemento.TimbreFiscalDigital.UUID != stamped.UUID {
+		t.Errorf("Complemento of the stamped invoice = %+v, want UUID %q", rebuilt.Complemento, stamped.UUID)
+	}
+}
+
+func TestServiceStampInvalid(t *testing.T) {
+	service := NewService(testIssuer, NewFakePAC(), 0.16)
+
+	inv := testInvoice(models.InvoiceLine{ProductID: 1, Description: "Balata delantera", Amount: 1, UnitPrice: 450})
+	inv.Receiver.RFC = "NO VALIDO"
+
+	stamped, err := service.Stamp(context.Background(), inv)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Stamp() error = %v, want a *ValidationError", err)
+	}
+	if !strings.Contains(verr.Error(), "Rfc del receptor no válido") {
+		t.Errorf("Stamp() error = %v, want the receiver RFC rejected", err)
+	}
+	if stamped.Status == models.InvoiceStamped || stamped.UUID != "" {
+		t.Errorf("invalid invoice got stamped: status %q, UUID %q", stamped.Status, stamped.UUID)
+	}
+}
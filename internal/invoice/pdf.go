@@ -0,0 +1,149 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	pageMargin   = 50
+	fontSize     = 9
+	lineHeight   = 12
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+// RenderPDF writes the printable representation of a CFDI as a PDF document.
+//
+// It uses a monospaced standard font, so the document needs no embedded fonts and columns align by padding.
+func RenderPDF(c Comprobante) []byte {
+	lines := []string{
+		fmt.Sprintf("FACTURA %s-%s", c.Serie, c.Folio),
+		"",
+		fmt.Sprintf("Emisor:   %s", c.Emisor.Nombre),
+		fmt.Sprintf("RFC:      %s   Régimen fiscal: %s", c.Emisor.Rfc, c.Emisor.RegimenFiscal),
+		fmt.Sprintf("Lugar de expedición: %s   Fecha: %s", c.LugarExpedicion, c.Fecha),
+		"",
+		fmt.Sprintf("Receptor: %s", c.Receptor.Nombre),
+		fmt.Sprintf("RFC:      %s   Régimen fiscal: %s", c.Receptor.Rfc, c.Receptor.RegimenFiscalReceptor),
+		fmt.Sprintf("Domicilio fiscal: %s   Uso CFDI: %s", c.Receptor.DomicilioFiscalReceptor, c.Receptor.UsoCFDI),
+		"",
+		fmt.Sprintf("%-8s %5s %-38s %11s %10s %11s", "Clave", "Cant.", "Descripción", "P. unitario", "Descuento", "Importe"),
+		strings.Repeat("-", 88),
+	}
+
+	for _, concept := range c.Conceptos {
+		description := []rune(concept.Descripcion)
+		if len(description) > 38 {
+			description = description[:38]
+		}
+		lines = append(lines, fmt.Sprintf("%-8s %5d %-38s %11s %10s %11s",
+			concept.ClaveProdServ,
+			concept.Cantidad,
+			string(description),
+			concept.ValorUnitario,
+			concept.Descuento,
+			concept.Importe,
+		))
+	}
+
+	lines = append(lines,
+		strings.Repeat("-", 88),
+		fmt.Sprintf("%76s %11s", "Subtotal:", c.SubTotal),
+		fmt.Sprintf("%76s %11s", "Descuento:", c.Descuento),
+	)
+	if c.Impuestos != nil {
+		for _, transfer := range c.Impuestos.Traslados {
+			lines = append(lines, fmt.Sprintf("%76s %11s", "IVA "+transfer.TasaOCuota+":", transfer.Importe))
+		}
+	}
+	lines = append(lines,
+		fmt.Sprintf("%76s %11s", "Total:", c.Total),
+		"",
+		fmt.Sprintf("Moneda: %s   Método de pago: %s   Forma de pago: %s", c.Moneda, c.MetodoPago, c.FormaPago),
+	)
+
+	if c.Complemento != nil {
+		stamp := c.Complemento.TimbreFiscalDigital
+		lines = append(lines,
+			"",
+			fmt.Sprintf("Folio fiscal (UUID): %s", stamp.UUID),
+			fmt.Sprintf("Fecha de timbrado:   %s", stamp.FechaTimbrado),
+		)
+	}
+	lines = append(lines, "", "Este documento es una representación impresa de un CFDI")
+
+	return writePDF(lines)
+}
+
+// writePDF lays out text lines over as many letter sized pages as needed
+func writePDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects 1 and 2 are the catalog and the page tree, 3 the font, then a page and its content per page
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"}
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		pageID := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, lineHeight, pageMargin, pageHeight-pageMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, pageID+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = doc.Len()
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return doc.Bytes()
+}
+
+// escapePDFText escapes a string for a PDF literal and converts it to the single byte WinAnsi encoding, characters
+// outside Latin-1 are replaced by a question mark
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x100:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package invoice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Patterns and catalogs taken from the cfdv40.xsd schema and the SAT catalogs it references
+var (
+	rfcPattern         = regexp.MustCompile(`^[A-Z&Ñ]{3,4}[0-9]{2}(0[1-9]|1[012])(0[1-9]|[12][0-9]|3[01])[A-Z0-9]{2}[0-9A]$`)
+	datePattern        = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+	zipCodePattern     = regexp.MustCompile(`^[0-9]{5}$`)
+	regimePattern      = regexp.MustCompile(`^[0-9]{3}$`)
+	cfdiUsePattern     = regexp.MustCompile(`^([GDI][0-9]{2}|S01|CP01|CN01)$`)
+	productKeyPattern  = regexp.MustCompile(`^[0-9]{8}$`)
+	certificatePattern = regexp.MustCompile(`^[0-9]{20}$`)
+	paymentFormPattern = regexp.MustCompile(`^[0-9]{2}$`)
+)
+
+// ValidationError lists every rule of the schema a CFDI does not follow
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid CFDI: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks a CFDI against the restrictions of the CFDI 4.0 schema and the arithmetic the SAT verifies
+// when stamping. The seal and certificate are optional because the PAC adds them.
+func Validate(c Comprobante) error {
+	v := &ValidationError{}
+
+	v.check(c.Version == "4.0", "Version debe ser 4.0")
+	v.check(length(c.Serie) <= 25, "Serie excede 25 caracteres")
+	v.check(length(c.Folio) >= 1 && length(c.Folio) <= 40, "Folio debe tener entre 1 y 40 caracteres")
+	v.check(datePattern.MatchString(c.Fecha), "Fecha no tiene el formato AAAA-MM-DDThh:mm:ss")
+	v.check(c.NoCertificado == "" || certificatePattern.MatchString(c.NoCertificado), "NoCertificado debe tener 20 dígitos")
+	v.check(c.Moneda == CurrencyMXN, "Moneda debe ser MXN")
+	v.check(c.TipoDeComprobante == IncomeVoucher, "TipoDeComprobante debe ser I")
+	v.check(c.Exportacion == NoExport, "Exportacion debe ser 01")
+	v.check(zipCodePattern.MatchString(c.LugarExpedicion), "LugarExpedicion debe ser un código postal")
+
+	switch c.MetodoPago {
+	case SingleExhibition:
+		v.check(paymentFormPattern.MatchString(c.FormaPago) && c.FormaPago != ToBeDefined, "FormaPago no válida para pago en una exhibición")
+	case DeferredPayment:
+		v.check(c.FormaPago == ToBeDefined, "FormaPago debe ser 99 para pago en parcialidades")
+	default:
+		v.problem("MetodoPago debe ser PUE o PPD")
+	}
+
+	v.check(rfcPattern.MatchString(c.Emisor.Rfc), "Rfc del emisor no válido")
+	v.check(length(c.Emisor.Nombre) >= 1 && length(c.Emisor.Nombre) <= 300, "Nombre del emisor no válido")
+	v.check(regimePattern.MatchString(c.Emisor.RegimenFiscal), "RegimenFiscal del emisor no válido")
+
+	v.check(rfcPattern.MatchString(c.Receptor.Rfc), "Rfc del receptor no válido")
+	v.check(length(c.Receptor.Nombre) >= 1 && length(c.Receptor.Nombre) <= 300, "Nombre del receptor no válido")
+	v.check(zipCodePattern.MatchString(c.Receptor.DomicilioFiscalReceptor), "DomicilioFiscalReceptor debe ser un código postal")
+	v.check(regimePattern.MatchString(c.Receptor.RegimenFiscalReceptor), "RegimenFiscalReceptor no válido")
+	v.check(cfdiUsePattern.MatchString(c.Receptor.UsoCFDI), "UsoCFDI no válido")
+
+	v.check(len(c.Conceptos) > 0, "El comprobante debe tener al menos un concepto")
+
+	var subtotal, discount, tax Money
+	for i, concept := range c.Conceptos {
+		n := i + 1
+		v.check(productKeyPattern.MatchString(concept.ClaveProdServ), fmt.Sprintf("ClaveProdServ del concepto %d no válida", n))
+		v.check(concept.Cantidad > 0, fmt.Sprintf("Cantidad del concepto %d debe ser mayor a cero", n))
+		v.check(concept.ClaveUnidad != "", fmt.Sprintf("ClaveUnidad del concepto %d es obligatoria", n))
+		v.check(length(concept.Descripcion) >= 1 && length(concept.Descripcion) <= 1000, fmt.Sprintf("Descripcion del concepto %d no válida", n))
+		v.check(concept.ValorUnitario >= 0, fmt.Sprintf("ValorUnitario del concepto %d no puede ser negativo", n))
+		v.check(concept.Importe == concept.ValorUnitario*Money(concept.Cantidad), fmt.Sprintf("Importe del concepto %d no corresponde a cantidad por valor unitario", n))
+		v.check(concept.Descuento >= 0 && concept.Descuento <= concept.Importe, fmt.Sprintf("Descuento del concepto %d no válido", n))
+		v.check(concept.ObjetoImp == TaxableObject, fmt.Sprintf("ObjetoImp del concepto %d debe ser 02", n))
+
+		subtotal += concept.Importe
+		discount += concept.Descuento
+
+		if concept.Impuestos == nil {
+			v.problem(fmt.Sprintf("El concepto %d debe desglosar sus impuestos", n))
+			continue
+		}
+		for _, transfer := range concept.Impuestos.Traslados {
+			v.check(transfer.Base == concept.Importe-concept.Descuento, fmt.Sprintf("Base del traslado del concepto %d no corresponde al importe", n))
+			v.check(transfer.Impuesto == IVATax && transfer.TipoFactor == RateFactor, fmt.Sprintf("Traslado del concepto %d debe ser IVA a tasa", n))
+			tax += transfer.Importe
+		}
+	}
+
+	v.check(c.SubTotal == subtotal, "SubTotal no corresponde a la suma de los importes")
+	v.check(c.Descuento == discount, "Descuento no corresponde a la suma de los descuentos")
+
+	if c.Impuestos == nil {
+		v.problem("El comprobante debe desglosar sus impuestos")
+	} else {
+		var transferred Money
+		for _, transfer := range c.Impuestos.Traslados {
+			transferred += transfer.Importe
+		}
+		v.check(c.Impuestos.TotalImpuestosTrasladados == tax && transferred == tax, "Impuestos no corresponden a la suma de los conceptos")
+		tax = c.Impuestos.TotalImpuestosTrasladados
+	}
+
+	v.check(c.Total == c.SubTotal-c.Descuento+tax, "Total no corresponde a subtotal menos descuento más impuestos")
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+
+	return nil
+}
+
+func (e *ValidationError) check(ok bool, problem string) {
+	if !ok {
+		e.problem(problem)
+	}
+}
+
+func (e *ValidationError) problem(problem string) {
+	e.Problems = append(e.Problems, problem)
+}
+
+func length(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,150 @@
+package invoice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() Comprobante {
+		return Build(testIssuer, testInvoice(
+			models.InvoiceLine{ProductID: 1, SATKey: "25174004", Description: "Balata delantera", Amount: 2, UnitPrice: 450},
+			models.InvoiceLine{ProductID: 2, Description: "Filtro de aceite", Amount: 1, UnitPrice: 99.99, Discount: 5.55},
+		))
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(c *Comprobante)
+		want   []string
+	}{
+		{
+			name:   "valid document",
+			mutate: func(c *Comprobante) {},
+		},
+		{
+			name:   "valid document with installments",
+			mutate: func(c *Comprobante) { c.MetodoPago, c.FormaPago = DeferredPayment, ToBeDefined },
+		},
+		{
+			name:   "invalid issuer RFC",
+			mutate: func(c *Comprobante) { c.Emisor.Rfc = "EKU900317" },
+			want:   []string{"Rfc del emisor no válido"},
+		},
+		{
+			name: "invalid receiver",
+			mutate: func(c *Comprobante) {
+				c.Receptor.Rfc = "xaxx010101000"
+				c.Receptor.DomicilioFiscalReceptor = "4507"
+				c.Receptor.UsoCFDI = "Z01"
+			},
+			want: []string{
+				"Rfc del receptor no válido",
+				"DomicilioFiscalReceptor debe ser un código postal",
+				"UsoCFDI no válido",
+			},
+		},
+		{
+			name:   "installments with a payment form",
+			mutate: func(c *Comprobante) { c.MetodoPago, c.FormaPago = DeferredPayment, "01" },
+			want:   []string{"FormaPago debe ser 99 para pago en parcialidades"},
+		},
+		{
+			name:   "single exhibition without payment form",
+			mutate: func(c *Comprobante) { c.FormaPago = ToBeDefined },
+			want:   []string{"FormaPago no válida para pago en una exhibición"},
+		},
+		{
+			name:   "unknown payment method",
+			mutate: func(c *Comprobante) { c.MetodoPago = "" },
+			want:   []string{"MetodoPago debe ser PUE o PPD"},
+		},
+		{
+			name:   "invalid date",
+			mutate: func(c *Comprobante) { c.Fecha = "2026-10-18 12:30:00" },
+			want:   []string{"Fecha no tiene el formato AAAA-MM-DDThh:mm:ss"},
+		},
+		{
+			name:   "without conceptos",
+			mutate: func(c *Comprobante) { c.Conceptos = nil },
+			want: []string{
+				"El comprobante debe tener al menos un concepto",
+				"SubTotal no corresponde a la suma de los importes",
+				"Descuento no corresponde a la suma de los descuentos",
+				"Impuestos no corresponden a la suma de los conceptos",
+			},
+		},
+		{
+			name:   "invalid product key",
+			mutate: func(c *Comprobante) { c.Conceptos[0].ClaveProdServ = "2517" },
+			want:   []string{"ClaveProdServ del concepto 1 no válida"},
+		},
+		{
+			name: "amount that is not quantity by unit price",
+			mutate: func(c *Comprobante) {
+				c.Conceptos[1].Importe++
+				c.Conceptos[1].Impuestos.Traslados[0].Base++
+				c.SubTotal++
+				c.Total++
+			},
+			want: []string{"Importe del concepto 2 no corresponde a cantidad por valor unitario"},
+		},
+		{
+			name:   "discount over the amount",
+			mutate: func(c *Comprobante) { c.Conceptos[1].Descuento = c.Conceptos[1].Importe + 1 },
+			want: []string{
+				"Descuento del concepto 2 no válido",
+				"Base del traslado del concepto 2 no corresponde al importe",
+				"Descuento no corresponde a la suma de los descuentos",
+			},
+		},
+		{
+			name:   "line without taxes",
+			mutate: func(c *Comprobante) { c.Conceptos[0].Impuestos = nil },
+			want: []string{
+				"El concepto 1 debe desglosar sus impuestos",
+				"Impuestos no corresponden a la suma de los conceptos",
+			},
+		},
+		{
+			name:   "taxes that are not the sum of the lines",
+			mutate: func(c *Comprobante) { c.Impuestos.TotalImpuestosTrasladados++; c.Total++ },
+			want:   []string{"Impuestos no corresponden a la suma de los conceptos"},
+		},
+		{
+			name:   "total off by a cent",
+			mutate: func(c *Comprobante) { c.Total-- },
+			want:   []string{"Total no corresponde a subtotal menos descuento más impuestos"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.mutate(&c)
+
+			err := Validate(c)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			if len(verr.Problems) != len(tt.want) {
+				t.Fatalf("Validate() problems = %q, want %q", verr.Problems, tt.want)
+			}
+			for i := range tt.want {
+				if verr.Problems[i] != tt.want[i] {
+					t.Errorf("problem %d = %q, want %q", i, verr.Problems[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
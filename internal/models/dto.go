@@ -4,14 +4,47 @@ import (
 	"time"
 )
 
+// ProductDTO incoming product, its brand is given by id or by the name or an alias of it
 type ProductDTO struct {
 	Classification string  `json:"classification"`
 	Brand          string  `json:"brand"`
+	BrandID        int     `json:"brand_id"`
 	PublicPrice    float32 `json:"public_price"`
 	ProviderPrice  float32 `json:"provider_price"`
 	Amount         int     `json:"amount,omitempty"`
+	MinStock       int     `json:"min_stock"`
+	MaxStock       int     `json:"max_stock"`
 	CategoryID     int     `json:"category_id"`
 	ProviderID     int     `json:"provider_id"`
+	BranchID       int     `json:"branch_id,omitempty"`
+}
+
+// ProductFilter narrows down a product listing, zero values do not filter. Year narrows the vehicle down to a
+// single year of its range and a category includes the categories under it
+type ProductFilter struct {
+	Code       string
+	VehicleID  int
+	Year       int
+	CategoryID int
+	BrandID    int
+}
+
+// ProductCodesDTO incoming codes of a product, every one is optional and the incoming barcodes replace the current
+// ones
+type ProductCodesDTO struct {
+	SKU        string   `json:"sku"`
+	PartNumber string   `json:"part_number"`
+	OEMNumber  string   `json:"oem_number"`
+	Barcodes   []string `json:"barcodes"`
+}
+
+// ProductProviderDTO incoming terms a provider sells a product with, LeadTime is in days and SKU is optional
+type ProductProviderDTO struct {
+	ProviderID int     `json:"provider_id"`
+	SKU        string  `json:"sku"`
+	Cost       float32 `json:"cost"`
+	LeadTime   int     `json:"lead_time"`
+	Preferred  bool    `json:"preferred"`
 }
 
 type ProviderDTO struct {
@@ -22,13 +55,45 @@ type ProviderDTO struct {
 	Address    string `json:"address"`
 }
 
+// SaleDTO incoming sale, unit prices and discounts of lines are only taken into account when a manager authorized
+// them with ManagerOverride. Totals are always computed from the lines
 type SaleDTO struct {
-	ProductID int       `json:"product_id"`
-	ClientID  int       `json:"client_id"`
-	Date      time.Time `json:"date"`
-	Total     float32   `json:"total"`
-	Subtotal  float32   `json:"subtotal"`
-	Amount    int       `json:"amount"`
+	SessionID       int                 `json:"session_id"`
+	ClientID        int                 `json:"client_id"`
+	Date            time.Time           `json:"date"`
+	Total           float32             `json:"-"`
+	Subtotal        float32             `json:"-"`
+	ManagerOverride *ManagerOverrideDTO `json:"manager_override"`
+	OnCredit        bool                `json:"on_credit"`
+	Lines           []SaleLineDTO       `json:"lines"`
+	Payments        []PaymentDTO        `json:"payments"`
+}
+
+// ManagerOverrideDTO credentials of the manager authorizing the prices of a sale
+type ManagerOverrideDTO struct {
+	ManagerID int    `json:"manager_id"`
+	PIN       string `json:"pin"`
+}
+
+type SaleLineDTO struct {
+	ProductID   int     `json:"product_id"`
+	Amount      int     `json:"amount"`
+	UnitPrice   float32 `json:"unit_price"`
+	Discount    float32 `json:"discount"`
+	PromotionID int     `json:"-"`
+}
+
+// PaymentDTO incoming payment of a sale, reference is only needed for card and transfer payments
+type PaymentDTO struct {
+	Method    string  `json:"method"`
+	Amount    float32 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// SalePaymentDTO payments received over a pending sale at an open register session
+type SalePaymentDTO struct {
+	SessionID int          `json:"session_id"`
+	Payments  []PaymentDTO `json:"payments"`
 }
 
 type DeliveryDTO struct {
@@ -36,10 +101,247 @@ type DeliveryDTO struct {
 	ProviderID   int    `json:"provider_id"`
 	DeliveryDate string `json:"delivery_date"`
 	Amount       int    `json:"amount"`
+	BranchID     int    `json:"branch_id"`
 }
 
 type ClientDTO struct {
-	Name    string `json:"name,omitempty"`
-	Address string `json:"address,omitempty"`
-	Phone   string `json:"phone,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	Address     string  `json:"address,omitempty"`
+	Phone       string  `json:"phone,omitempty"`
+	CreditLimit float32 `json:"credit_limit"`
+}
+
+// ReturnDTO incoming return, it is registered at an open register session whose drawer pays the cash refund
+type ReturnDTO struct {
+	SessionID int             `json:"session_id"`
+	SaleID    int             `json:"sale_id"`
+	Reason    string          `json:"reason"`
+	Lines     []ReturnLineDTO `json:"lines"`
+}
+
+type ReturnLineDTO struct {
+	LineID  int  `json:"line_id"`
+	Amount  int  `json:"amount"`
+	Damaged bool `json:"damaged"`
+}
+
+// PromotionDTO incoming promotion, dates come as YYYY-MM-DD and scope fields are optional
+type PromotionDTO struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Percent    float32 `json:"percent"`
+	Buy        int     `json:"buy"`
+	Free       int     `json:"free"`
+	ProductID  int     `json:"product_id"`
+	Brand      string  `json:"brand"`
+	CategoryID int     `json:"category_id"`
+	ClientID   int     `json:"client_id"`
+	StartsAt   string  `json:"starts_at"`
+	EndsAt     string  `json:"ends_at"`
+	Active     bool    `json:"active"`
+}
+
+// OpenRegisterDTO incoming register session, sales charged at it take stock from its branch, the main one when
+// no branch is given
+type OpenRegisterDTO struct {
+	Cashier      string  `json:"cashier"`
+	OpeningFloat float32 `json:"opening_float"`
+	BranchID     int     `json:"branch_id"`
+}
+
+type CashMovementDTO struct {
+	Kind    string  `json:"kind"`
+	Amount  float32 `json:"amount"`
+	Concept string  `json:"concept"`
+}
+
+// CloseRegisterDTO money counted in the drawer at the end of the shift, indexed by payment method
+type CloseRegisterDTO struct {
+	Counted map[string]float32 `json:"counted"`
+}
+
+// QuoteDTO incoming quote, unit prices are taken from the catalog so only product and amount of lines are used
+type QuoteDTO struct {
+	ClientID   int           `json:"client_id"`
+	ValidUntil string        `json:"valid_until"`
+	Lines      []SaleLineDTO `json:"lines"`
+}
+
+// ConvertQuoteDTO register session and payments of the sale a quote is turned into
+type ConvertQuoteDTO struct {
+	SessionID int          `json:"session_id"`
+	OnCredit  bool         `json:"on_credit"`
+	Payments  []PaymentDTO `json:"payments"`
+}
+
+// ClientPaymentDTO payment of a client over its open credit sales, applied from the oldest one
+type ClientPaymentDTO struct {
+	SessionID int     `json:"session_id"`
+	Method    string  `json:"method"`
+	Amount    float32 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// TaxDataDTO tax data of a client needed to invoice its sales
+type TaxDataDTO struct {
+	RFC       string `json:"rfc"`
+	LegalName string `json:"legal_name"`
+	TaxRegime string `json:"tax_regime"`
+	ZipCode   string `json:"zip_code"`
+	CfdiUse   string `json:"cfdi_use"`
+}
+
+// PurchaseOrderDTO incoming purchase order, ExpectedDate is the date the provider should deliver it at the branch,
+// the main one when no branch is given
+type PurchaseOrderDTO struct {
+	ProviderID   int                    `json:"provider_id"`
+	BranchID     int                    `json:"branch_id"`
+	ExpectedDate string                 `json:"expected_date"`
+	Lines        []PurchaseOrderLineDTO `json:"lines"`
+}
+
+// PurchaseOrderLineDTO product of an incoming purchase order, a unit cost of 0 takes the provider price of the product
+type PurchaseOrderLineDTO struct {
+	ProductID int     `json:"product_id"`
+	Amount    int     `json:"amount"`
+	UnitCost  float32 `json:"unit_cost"`
+}
+
+// GoodsReceiptDTO incoming shipment of a provider, notes are optional
+type GoodsReceiptDTO struct {
+	Notes string                `json:"notes"`
+	Lines []GoodsReceiptLineDTO `json:"lines"`
+}
+
+// GoodsReceiptLineDTO units that came for a purchase order line. Received units go to stock, damaged ones to the
+// damaged stock and rejected ones back to the provider; a unit cost of 0 keeps the cost of the order
+type GoodsReceiptLineDTO struct {
+	LineID   int     `json:"line_id"`
+	Received int     `json:"received"`
+	Damaged  int     `json:"damaged"`
+	Rejected int     `json:"rejected"`
+	UnitCost float32 `json:"unit_cost"`
+}
+
+// InventoryCountDTO incoming count of a branch, the main one when no branch is given. A cycle count takes the given
+// products and the ones of the given category and the categories under it
+type InventoryCountDTO struct {
+	Description string `json:"description"`
+	BranchID    int    `json:"branch_id"`
+	Kind        string `json:"kind"`
+	CategoryID  int    `json:"category_id"`
+	ProductIDs  []int  `json:"product_ids"`
+}
+
+// CountEntriesDTO batch of counted units. With Accumulate the units are added to the ones already counted, so a
+// scanner can upload one entry per scan
+type CountEntriesDTO struct {
+	Accumulate bool            `json:"accumulate"`
+	Lines      []CountEntryDTO `json:"lines"`
+}
+
+// CountEntryDTO units counted of a product
+type CountEntryDTO struct {
+	ProductID int `json:"product_id"`
+	Counted   int `json:"counted"`
+}
+
+// PostCountDTO approval of the variances of a count, either of the given products or, with ApproveAll, of every
+// counted product
+type PostCountDTO struct {
+	Reason     string `json:"reason"`
+	ProductIDs []int  `json:"product_ids"`
+	ApproveAll bool   `json:"approve_all"`
+}
+
+// BranchDTO incoming branch, the address is optional
+type BranchDTO struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// TransferDTO incoming transfer, notes are optional
+type TransferDTO struct {
+	OriginID      int               `json:"origin_id"`
+	DestinationID int               `json:"destination_id"`
+	Notes         string            `json:"notes"`
+	Lines         []TransferLineDTO `json:"lines"`
+}
+
+// TransferLineDTO units of a product to move
+type TransferLineDTO struct {
+	ProductID int `json:"product_id"`
+	Amount    int `json:"amount"`
+}
+
+// VehicleDTO incoming vehicle, engine and trim are optional and a missing last year means a single year
+type VehicleDTO struct {
+	Make     string `json:"make"`
+	Model    string `json:"model"`
+	YearFrom int    `json:"year_from"`
+	YearTo   int    `json:"year_to"`
+	Engine   string `json:"engine"`
+	Trim     string `json:"trim"`
+}
+
+// FitmentDTO incoming vehicle a product fits, notes are optional
+type FitmentDTO struct {
+	VehicleID int    `json:"vehicle_id"`
+	Notes     string `json:"notes"`
+}
+
+// FitmentRowDTO row of a bulk fitment import, the product is found by its SKU, part number, OEM number or barcode
+// and the vehicle is added to the catalog when it is not there yet
+type FitmentRowDTO struct {
+	Line    int
+	Code    string
+	Notes   string
+	Vehicle VehicleDTO
+}
+
+// CrossReferenceDTO incoming equivalence of a product, it is either another product or the part number of another
+// brand. The brand and notes are optional
+type CrossReferenceDTO struct {
+	Kind         string `json:"kind"`
+	EquivalentID int    `json:"equivalent_id"`
+	PartNumber   string `json:"part_number"`
+	PartBrand    string `json:"part_brand"`
+	Notes        string `json:"notes"`
+}
+
+// PriceUpdateDTO incoming bulk price change, products are picked by brand, category and preferred provider and
+// the target price of each one is moved by a percent or an absolute amount. A dry run only previews the change
+type PriceUpdateDTO struct {
+	Brand      string  `json:"brand"`
+	CategoryID int     `json:"category_id"`
+	ProviderID int     `json:"provider_id"`
+	Target     string  `json:"target"`
+	Mode       string  `json:"mode"`
+	Value      float32 `json:"value"`
+	Rounding   string  `json:"rounding"`
+	Reason     string  `json:"reason"`
+	DryRun     bool    `json:"dry_run"`
+}
+
+// MarkupRuleDTO incoming markup rule, it is scoped by exactly one of category, brand or provider. Markup and
+// minimum margin are percents
+type MarkupRuleDTO struct {
+	CategoryID int     `json:"category_id"`
+	Brand      string  `json:"brand"`
+	ProviderID int     `json:"provider_id"`
+	Markup     float32 `json:"markup"`
+	MinMargin  float32 `json:"min_margin"`
+	Block      bool    `json:"block"`
+}
+
+// CategoryDTO incoming category, a parent of 0 makes it a root category
+type CategoryDTO struct {
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id"`
+}
+
+// BrandDTO incoming brand, the incoming aliases replace the current ones
+type BrandDTO struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
 }
@@ -5,19 +5,53 @@ import (
 )
 
 type Product struct {
-	ProductID      int      `json:"product_id,omitempty"`
-	Classification string   `json:"classification"`
-	Brand          string   `json:"brand,omitempty"`
-	PublicPrice    float32  `json:"public_price"`
-	ProviderPrice  float32  `json:"provider_price"`
-	Amount         int      `json:"amount"`
-	Category       Category `json:"category,omitempty"`
-	Provider       Provider `json:"provider,omitempty"`
+	ProductID      int               `json:"product_id,omitempty"`
+	SKU            string            `json:"sku,omitempty"`
+	PartNumber     string            `json:"part_number,omitempty"`
+	OEMNumber      string            `json:"oem_number,omitempty"`
+	Barcodes       []string          `json:"barcodes,omitempty"`
+	Classification string            `json:"classification"`
+	Brand          string            `json:"brand,omitempty"`
+	BrandID        int               `json:"brand_id,omitempty"`
+	PublicPrice    float32           `json:"public_price"`
+	ProviderPrice  float32           `json:"provider_price"`
+	Amount         int               `json:"amount"`
+	Damaged        int               `json:"damaged"`
+	MinStock       int               `json:"min_stock"`
+	MaxStock       int               `json:"max_stock"`
+	Category       Category          `json:"category,omitempty"`
+	Provider       Provider          `json:"provider,omitempty"`
+	Providers      []ProductProvider `json:"providers,omitempty"`
+	Stock          []BranchStock     `json:"stock,omitempty"`
+	InTransit      int               `json:"in_transit"`
+	Alternatives   []CrossReference  `json:"alternatives,omitempty"`
 }
 
+// ProductProvider terms a provider sells a product with, LeadTime is in days
+type ProductProvider struct {
+	SKU       string   `json:"sku"`
+	Cost      float32  `json:"cost"`
+	LeadTime  int      `json:"lead_time"`
+	Preferred bool     `json:"preferred"`
+	Provider  Provider `json:"provider"`
+}
+
+// Category group of products, it nests under its parent category. Path names it from its root down, and Products
+// counts the products directly in it
 type Category struct {
 	CategoryID int    `json:"category_id,omitempty"`
 	Name       string `json:"name,omitempty"`
+	ParentID   int    `json:"parent_id,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Products   int    `json:"products,omitempty"`
+}
+
+// Brand brand of the parts sold, aliases are the other spellings it is known by
+type Brand struct {
+	BrandID  int      `json:"brand_id"`
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	Products int      `json:"products"`
 }
 
 type Provider struct {
@@ -30,22 +64,590 @@ type Provider struct {
 }
 
 type Delivery struct {
+	OrderID      int       `json:"order_id,omitempty"`
 	DeliveryDate time.Time `json:"delivery_date,omitempty"`
 	Product      Product   `json:"product,omitempty"`
 	Provider     Provider  `json:"provider,omitempty"`
 	Amount       int       `json:"amount,omitempty"`
 }
 
+// Sale statuses, a sale is finalized once its payments cover the total
+const (
+	SaleStatusPending   = "pending"
+	SaleStatusFinalized = "finalized"
+)
+
 type Sale struct {
-	SaleID   int       `json:"sale_id,omitempty"`
-	Date     time.Time `json:"date,omitempty"`
-	Amount   int       `json:"amount"`
-	Total    float32   `json:"total,omitempty"`
-	SubTotal float32   `json:"sub_total,omitempty"`
-	Product  Product   `json:"product,omitempty"`
+	SaleID          int        `json:"sale_id,omitempty"`
+	Date            time.Time  `json:"date,omitempty"`
+	Status          string     `json:"status"`
+	Total           float32    `json:"total,omitempty"`
+	SubTotal        float32    `json:"sub_total,omitempty"`
+	Tax             float32    `json:"tax"`
+	ManagerOverride bool       `json:"manager_override"`
+	OnCredit        bool       `json:"on_credit"`
+	Refunded        float32    `json:"refunded"`
+	Client          Client     `json:"client,omitempty"`
+	Lines           []SaleLine `json:"lines"`
+	Payments        []Payment  `json:"payments"`
+}
+
+type SaleLine struct {
+	LineID    int       `json:"line_id,omitempty"`
+	Amount    int       `json:"amount"`
+	Returned  int       `json:"returned"`
+	UnitPrice float32   `json:"unit_price"`
+	Discount  float32   `json:"discount"`
+	Cost      float32   `json:"cost"`
+	Promotion Promotion `json:"promotion,omitempty"`
+	Product   Product   `json:"product,omitempty"`
+}
+
+// Payment methods accepted on a sale
+const (
+	PaymentCash        = "cash"
+	PaymentCard        = "card"
+	PaymentTransfer    = "transfer"
+	PaymentStoreCredit = "store_credit"
+)
+
+// SaleReceipt amounts of a sale after registering it or paying it
+type SaleReceipt struct {
+	SaleID   int     `json:"sale_id"`
+	Status   string  `json:"status"`
+	SubTotal float32 `json:"sub_total"`
+	Tax      float32 `json:"tax"`
+	Total    float32 `json:"total"`
+	Paid     float32 `json:"paid"`
+	Change   float32 `json:"change"`
+}
+
+type Payment struct {
+	PaymentID int       `json:"payment_id,omitempty"`
+	Date      time.Time `json:"date,omitempty"`
+	Method    string    `json:"method"`
+	Amount    float32   `json:"amount"`
+	Reference string    `json:"reference,omitempty"`
+	Change    float32   `json:"change"`
+}
+
+// Return reason codes accepted on a sale return
+const (
+	ReturnReasonDefective = "defective"
+	ReturnReasonWrongPart = "wrong_part"
+	ReturnReasonNotNeeded = "not_needed"
+	ReturnReasonWarranty  = "warranty"
+	ReturnReasonOther     = "other"
+)
+
+// Return units given back over a sale. Cash is the part of the refund handed out from the drawer of the session,
+// the rest settled what was still owed on the sale
+type Return struct {
+	ReturnID  int          `json:"return_id,omitempty"`
+	SaleID    int          `json:"sale_id"`
+	SessionID int          `json:"session_id,omitempty"`
+	Date      time.Time    `json:"date,omitempty"`
+	Reason    string       `json:"reason"`
+	Refund    float32      `json:"refund"`
+	Cash      float32      `json:"cash"`
+	Lines     []ReturnLine `json:"lines"`
+}
+
+type ReturnLine struct {
+	LineID  int     `json:"line_id"`
+	Amount  int     `json:"amount"`
+	Damaged bool    `json:"damaged"`
+	Refund  float32 `json:"refund"`
+	Product Product `json:"product,omitempty"`
+}
+
+// SalesReport sales of a period with the refunds of that period already discounted, CostOfGoods is the cost of
+// the units sold less the cost of the ones returned to stock
+type SalesReport struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Sales       int       `json:"sales"`
+	Gross       float32   `json:"gross"`
+	Refunds     float32   `json:"refunds"`
+	Net         float32   `json:"net"`
+	CostOfGoods float32   `json:"cost_of_goods"`
 }
 
 type Client struct {
-	ClientID int `json:"client_id,omitempty"`
+	ClientID int         `json:"client_id,omitempty"`
+	Balance  float32     `json:"balance"`
+	TaxData  *TaxDataDTO `json:"tax_data,omitempty"`
 	ClientDTO
 }
+
+// ClientStatement open credit sales of a client grouped by age
+type ClientStatement struct {
+	Client    Client        `json:"client"`
+	Available float32       `json:"available"`
+	Aging     Aging         `json:"aging"`
+	Invoices  []OpenInvoice `json:"invoices"`
+}
+
+// Aging balance owed by a client split by days since the sale
+type Aging struct {
+	Current float32 `json:"0_30"`
+	Days60  float32 `json:"31_60"`
+	Days90  float32 `json:"61_90"`
+	Over90  float32 `json:"90_plus"`
+}
+
+// OpenInvoice credit sale that still has an amount pending to be paid, Refunded is what its returns settled
+type OpenInvoice struct {
+	SaleID   int       `json:"sale_id"`
+	Date     time.Time `json:"date"`
+	Days     int       `json:"days"`
+	Total    float32   `json:"total"`
+	Paid     float32   `json:"paid"`
+	Refunded float32   `json:"refunded"`
+	Balance  float32   `json:"balance"`
+}
+
+// Promotion kinds
+const (
+	// PromotionPercent takes a percentage off every unit
+	PromotionPercent = "percent"
+	// PromotionBuyXGetY gives Free units for every Buy units paid
+	PromotionBuyXGetY = "buy_x_get_y"
+)
+
+// Promotion a discount rule, every scope field left empty matches anything. Subcategories holds the categories
+// nested under CategoryID, a category promotion matches the products of any of them.
+type Promotion struct {
+	PromotionID   int       `json:"promotion_id,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Kind          string    `json:"kind,omitempty"`
+	Percent       float32   `json:"percent,omitempty"`
+	Buy           int       `json:"buy,omitempty"`
+	Free          int       `json:"free,omitempty"`
+	ProductID     int       `json:"product_id,omitempty"`
+	Brand         string    `json:"brand,omitempty"`
+	CategoryID    int       `json:"category_id,omitempty"`
+	ClientID      int       `json:"client_id,omitempty"`
+	Subcategories []int     `json:"-"`
+	StartsAt      time.Time `json:"starts_at,omitempty"`
+	EndsAt        time.Time `json:"ends_at,omitempty"`
+	Active        bool      `json:"active,omitempty"`
+}
+
+// Register session statuses
+const (
+	RegisterOpen   = "open"
+	RegisterClosed = "closed"
+)
+
+// Cash movement kinds
+const (
+	CashIn  = "in"
+	CashOut = "out"
+)
+
+// RegisterSession a cash drawer opened by a cashier for a shift
+type RegisterSession struct {
+	SessionID    int             `json:"session_id"`
+	BranchID     int             `json:"branch_id"`
+	Cashier      string          `json:"cashier"`
+	OpeningFloat float32         `json:"opening_float"`
+	Status       string          `json:"status"`
+	OpenedAt     time.Time       `json:"opened_at"`
+	ClosedAt     *time.Time      `json:"closed_at,omitempty"`
+	Movements    []CashMovement  `json:"movements,omitempty"`
+	Counts       []RegisterCount `json:"counts,omitempty"`
+}
+
+type CashMovement struct {
+	MovementID int       `json:"movement_id"`
+	Kind       string    `json:"kind"`
+	Amount     float32   `json:"amount"`
+	Concept    string    `json:"concept"`
+	Date       time.Time `json:"date"`
+}
+
+// RegisterCount expected against counted money of a payment method when closing a register session
+type RegisterCount struct {
+	Method      string  `json:"method"`
+	Expected    float32 `json:"expected"`
+	Counted     float32 `json:"counted"`
+	Difference  float32 `json:"difference"`
+	Discrepancy bool    `json:"discrepancy"`
+}
+
+// Quote statuses, an open quote past its validity date is reported as expired
+const (
+	QuoteOpen      = "open"
+	QuoteExpired   = "expired"
+	QuoteConverted = "converted"
+	QuoteCancelled = "cancelled"
+)
+
+// Quote price quote (cotización) of a list of products for a client
+type Quote struct {
+	QuoteID    int         `json:"quote_id"`
+	Date       time.Time   `json:"date"`
+	ValidUntil time.Time   `json:"valid_until"`
+	Status     string      `json:"status"`
+	SubTotal   float32     `json:"sub_total"`
+	Tax        float32     `json:"tax"`
+	Total      float32     `json:"total"`
+	SaleID     int         `json:"sale_id,omitempty"`
+	Client     Client      `json:"client"`
+	Lines      []QuoteLine `json:"lines"`
+}
+
+type QuoteLine struct {
+	Amount    int     `json:"amount"`
+	UnitPrice float32 `json:"unit_price"`
+	Discount  float32 `json:"discount"`
+	Product   Product `json:"product"`
+}
+
+// Invoice statuses, an invoice keeps its folio while it waits to be stamped
+const (
+	InvoicePending = "pending"
+	InvoiceStamped = "stamped"
+)
+
+// Invoice electronic invoice (CFDI) of a sale.
+//
+// Receiver data is copied from the client when the folio is assigned, so later changes to the client do not
+// alter an invoice already issued.
+type Invoice struct {
+	InvoiceID     int           `json:"invoice_id"`
+	SaleID        int           `json:"sale_id"`
+	Series        string        `json:"series"`
+	Folio         int           `json:"folio"`
+	Status        string        `json:"status"`
+	UUID          string        `json:"uuid,omitempty"`
+	Date          time.Time     `json:"date"`
+	StampedAt     *time.Time    `json:"stamped_at,omitempty"`
+	TaxRate       float32       `json:"tax_rate"`
+	PaymentMethod string        `json:"payment_method,omitempty"`
+	PaymentForm   string        `json:"payment_form,omitempty"`
+	SalePaid      bool          `json:"-"`
+	SaleTender    string        `json:"-"`
+	Receiver      TaxDataDTO    `json:"receiver"`
+	Lines         []InvoiceLine `json:"lines,omitempty"`
+	XML           string        `json:"-"`
+}
+
+// InvoiceLine product invoiced, SATKey is the key of the product in the SAT catalog
+type InvoiceLine struct {
+	ProductID   int     `json:"product_id"`
+	SATKey      string  `json:"sat_key"`
+	Description string  `json:"description"`
+	Amount      int     `json:"amount"`
+	UnitPrice   float32 `json:"unit_price"`
+	Discount    float32 `json:"discount"`
+}
+
+// Stock movement types of the inventory ledger
+const (
+	MovementSale       = "sale"
+	MovementReturn     = "return"
+	MovementDelivery   = "delivery"
+	MovementAdjustment = "adjustment"
+	MovementTransfer   = "transfer"
+)
+
+// StockMovement change of the stock of a product, Balance is the stock right after the change
+type StockMovement struct {
+	MovementID int       `json:"movement_id"`
+	Date       time.Time `json:"date"`
+	Kind       string    `json:"kind"`
+	Quantity   int       `json:"quantity"`
+	Balance    int       `json:"balance"`
+	Reference  string    `json:"reference"`
+	User       string    `json:"user"`
+	BranchID   int       `json:"branch_id,omitempty"`
+}
+
+// Kardex stock movements of a product between two dates with the stock it had before and after them
+type Kardex struct {
+	ProductID int             `json:"product_id"`
+	From      *time.Time      `json:"from,omitempty"`
+	To        *time.Time      `json:"to,omitempty"`
+	Opening   int             `json:"opening"`
+	Closing   int             `json:"closing"`
+	Movements []StockMovement `json:"movements"`
+}
+
+// LowStockProduct product whose stock reached its reorder point, Suggested is what takes it back to its maximum
+type LowStockProduct struct {
+	ProductID      int    `json:"product_id"`
+	Classification string `json:"classification"`
+	Brand          string `json:"brand"`
+	Amount         int    `json:"amount"`
+	MinStock       int    `json:"min_stock"`
+	MaxStock       int    `json:"max_stock"`
+	Suggested      int    `json:"suggested"`
+}
+
+// Purchase order statuses, an order can only be edited while it is a draft
+const (
+	PurchaseDraft     = "draft"
+	PurchaseSent      = "sent"
+	PurchasePartial   = "partially_received"
+	PurchaseReceived  = "received"
+	PurchaseCancelled = "cancelled"
+)
+
+// PurchaseOrder order of products to a provider
+type PurchaseOrder struct {
+	OrderID      int                 `json:"order_id"`
+	Status       string              `json:"status"`
+	Date         time.Time           `json:"date"`
+	ExpectedDate time.Time           `json:"expected_date"`
+	Total        float32             `json:"total"`
+	Provider     Provider            `json:"provider"`
+	Branch       Branch              `json:"branch"`
+	Lines        []PurchaseOrderLine `json:"lines"`
+}
+
+// PurchaseOrderLine product ordered, Received counts the units that already arrived
+type PurchaseOrderLine struct {
+	LineID   int     `json:"line_id"`
+	Amount   int     `json:"amount"`
+	Received int     `json:"received"`
+	UnitCost float32 `json:"unit_cost"`
+	Product  Product `json:"product"`
+}
+
+// GoodsReceipt shipment of a provider received against a purchase order
+type GoodsReceipt struct {
+	ReceiptID int                `json:"receipt_id"`
+	OrderID   int                `json:"order_id"`
+	Date      time.Time          `json:"date"`
+	Notes     string             `json:"notes"`
+	Lines     []GoodsReceiptLine `json:"lines"`
+}
+
+// GoodsReceiptLine units of a purchase order line that came in a shipment
+type GoodsReceiptLine struct {
+	LineID   int     `json:"line_id"`
+	Received int     `json:"received"`
+	Damaged  int     `json:"damaged"`
+	Rejected int     `json:"rejected"`
+	UnitCost float32 `json:"unit_cost"`
+	Product  Product `json:"product"`
+}
+
+// Kinds and statuses of a physical inventory count
+const (
+	CountFull      = "full"
+	CountCycle     = "cycle"
+	CountOpen      = "open"
+	CountPosted    = "posted"
+	CountCancelled = "cancelled"
+)
+
+// InventoryCount physical count of the whole store (full) or of a few products (cycle)
+type InventoryCount struct {
+	CountID     int                  `json:"count_id"`
+	Description string               `json:"description"`
+	Kind        string               `json:"kind"`
+	Status      string               `json:"status"`
+	BranchID    int                  `json:"branch_id"`
+	Reason      string               `json:"reason,omitempty"`
+	StartedAt   time.Time            `json:"started_at"`
+	ClosedAt    *time.Time           `json:"closed_at,omitempty"`
+	Products    int                  `json:"products"`
+	Counted     int                  `json:"counted"`
+	Variances   int                  `json:"variances"`
+	Lines       []InventoryCountLine `json:"lines,omitempty"`
+}
+
+// InventoryCountLine expected stock of a product when the count started against the units counted, Counted is nil
+// while the product has not been counted and Adjustment is the stock change posted for it
+type InventoryCountLine struct {
+	Expected     int     `json:"expected"`
+	Counted      *int    `json:"counted"`
+	Variance     int     `json:"variance"`
+	VarianceCost float32 `json:"variance_cost"`
+	Adjustment   *int    `json:"adjustment,omitempty"`
+	Product      Product `json:"product"`
+}
+
+// Branch store or warehouse with its own stock
+type Branch struct {
+	BranchID int    `json:"branch_id"`
+	Name     string `json:"name"`
+	Address  string `json:"address,omitempty"`
+}
+
+// BranchStock units of a product at a branch
+type BranchStock struct {
+	BranchID int    `json:"branch_id"`
+	Name     string `json:"name"`
+	Amount   int    `json:"amount"`
+}
+
+// Statuses of a transfer, its units leave the origin when it is sent and reach the destination when received
+const (
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+// Transfer document that moves stock from a branch to another
+type Transfer struct {
+	TransferID  int            `json:"transfer_id"`
+	Status      string         `json:"status"`
+	SentAt      time.Time      `json:"sent_at"`
+	ReceivedAt  *time.Time     `json:"received_at,omitempty"`
+	Notes       string         `json:"notes"`
+	Origin      Branch         `json:"origin"`
+	Destination Branch         `json:"destination"`
+	Lines       []TransferLine `json:"lines"`
+}
+
+// TransferLine units of a product moved by a transfer
+type TransferLine struct {
+	Amount  int     `json:"amount"`
+	Product Product `json:"product"`
+}
+
+// Vehicle model of a make sold in a range of years, an empty engine or trim covers all of them
+type Vehicle struct {
+	VehicleID int    `json:"vehicle_id"`
+	Make      string `json:"make"`
+	Model     string `json:"model"`
+	YearFrom  int    `json:"year_from"`
+	YearTo    int    `json:"year_to"`
+	Engine    string `json:"engine,omitempty"`
+	Trim      string `json:"trim,omitempty"`
+}
+
+// Fitment vehicle a product fits
+type Fitment struct {
+	Notes   string  `json:"notes,omitempty"`
+	Vehicle Vehicle `json:"vehicle"`
+}
+
+// FitmentImport result of a bulk fitment import, rows with errors are skipped
+type FitmentImport struct {
+	Imported int                  `json:"imported"`
+	Errors   []FitmentImportError `json:"errors"`
+}
+
+// FitmentImportError reason a row of a fitment import was skipped, Line counts the header as line 1
+type FitmentImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Kinds of equivalence between parts, a superseded part is replaced by its equivalent but not the other way around
+const (
+	EquivalenceExact       = "exact"
+	EquivalenceAlternative = "alternative"
+	EquivalenceSuperseded  = "superseded"
+)
+
+// CrossReference part a product can be replaced with, either one of our products or the part number of another
+// brand
+type CrossReference struct {
+	CrossReferenceID int      `json:"cross_reference_id"`
+	Kind             string   `json:"kind"`
+	Notes            string   `json:"notes,omitempty"`
+	PartNumber       string   `json:"part_number,omitempty"`
+	PartBrand        string   `json:"part_brand,omitempty"`
+	Product          *Product `json:"product,omitempty"`
+}
+
+// Costing methods the inventory can be valued with
+const (
+	CostingWeightedAverage = "weighted_average"
+	CostingFIFO            = "fifo"
+)
+
+// InventoryValuation worth of the inventory owned at the end of a date, units in transit between branches included
+type InventoryValuation struct {
+	Date     time.Time          `json:"date"`
+	Method   string             `json:"method"`
+	Units    int                `json:"units"`
+	Total    float32            `json:"total"`
+	Products []ProductValuation `json:"products"`
+}
+
+// ProductValuation worth of the units of a product, UnitCost is the value divided by the units
+type ProductValuation struct {
+	Amount   int     `json:"amount"`
+	UnitCost float32 `json:"unit_cost"`
+	Value    float32 `json:"value"`
+	Product  Product `json:"product"`
+}
+
+// Prices of a product and the origins of a change of them
+const (
+	PricePublic        = "public"
+	PriceProvider      = "provider"
+	PriceChangeManual  = "manual"
+	PriceChangeBulk    = "bulk"
+	PriceChangeReceipt = "receipt"
+)
+
+// Modes and rounding rules of a bulk price change
+const (
+	RepriceByPercent  = "percent"
+	RepriceByAmount   = "amount"
+	RoundToCents      = "cents"
+	RoundToPeso       = "peso"
+	RoundToNinetyNine = "ninety_nine"
+)
+
+// PriceChange change of a price of a product, Previous is nil for the first price it had
+type PriceChange struct {
+	ChangeID  int64     `json:"change_id"`
+	Date      time.Time `json:"date"`
+	Kind      string    `json:"kind"`
+	Previous  *float32  `json:"previous"`
+	Price     float32   `json:"price"`
+	Origin    string    `json:"origin"`
+	Reference string    `json:"reference,omitempty"`
+	User      string    `json:"user"`
+}
+
+// PriceUpdate result of a bulk price change, or what it would do when it is a dry run
+type PriceUpdate struct {
+	DryRun  bool              `json:"dry_run"`
+	Target  string            `json:"target"`
+	Updated int               `json:"updated"`
+	Blocked int               `json:"blocked"`
+	Lines   []PriceUpdateLine `json:"lines"`
+}
+
+// PriceUpdateLine price of a product before and after a bulk change. A public price left under the minimum price
+// of its markup rule carries the rule and a warning, and is blocked when the rule does not allow it
+type PriceUpdateLine struct {
+	Previous     float32     `json:"previous"`
+	Price        float32     `json:"price"`
+	MinimumPrice float32     `json:"minimum_price,omitempty"`
+	Rule         *MarkupRule `json:"rule,omitempty"`
+	Warning      string      `json:"warning,omitempty"`
+	Blocked      bool        `json:"blocked,omitempty"`
+	Product      Product     `json:"product"`
+}
+
+// MarkupRule markup over the provider price that suggests the public price of the products of a category, brand
+// or provider, and the minimum margin over the public price they may be sold with. Markup and margin are percents
+type MarkupRule struct {
+	RuleID     int     `json:"rule_id"`
+	CategoryID int     `json:"category_id,omitempty"`
+	Brand      string  `json:"brand,omitempty"`
+	ProviderID int     `json:"provider_id,omitempty"`
+	Markup     float32 `json:"markup"`
+	MinMargin  float32 `json:"min_margin"`
+	Block      bool    `json:"block"`
+}
+
+// PriceSuggestion public price a markup rule suggests for a cost and the lowest one keeping its minimum margin,
+// both are 0 when no rule applies
+type PriceSuggestion struct {
+	Cost         float32     `json:"cost"`
+	Suggested    float32     `json:"suggested"`
+	MinimumPrice float32     `json:"minimum_price"`
+	Rule         *MarkupRule `json:"rule"`
+}
@@ -0,0 +1,20 @@
+package pricing
+
+import (
+	"math"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// SuggestPrice returns the public price a markup rule gives to a cost
+func SuggestPrice(cost float32, rule models.MarkupRule) float32 {
+	return RoundCents(cost * (1 + rule.Markup/100))
+}
+
+// MinimumPrice returns the lowest public price that keeps the minimum margin of a markup rule over a cost, rounded
+// up to the cent so it never falls short of the margin
+func MinimumPrice(cost float32, rule models.MarkupRule) float32 {
+	price := float64(cost) / (1 - float64(rule.MinMargin)/100)
+	// Rounding to a hundredth of a cent first drops the noise of the division before rounding up
+	return float32(math.Ceil(math.Round(price*10000)/100) / 100)
+}
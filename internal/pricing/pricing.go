@@ -0,0 +1,41 @@
+package pricing
+
+import (
+	"math"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// DefaultTaxRate IVA rate used when no other rate is configured
+const DefaultTaxRate = 0.16
+
+// Calculator computes the amounts of a sale from its lines
+type Calculator struct {
+	TaxRate float32
+}
+
+// NewCalculator creates a calculator that charges the given tax rate, e.g. 0.16 for 16% IVA
+func NewCalculator(taxRate float32) Calculator {
+	return Calculator{TaxRate: taxRate}
+}
+
+// LineTotal returns the amount of a line once its discount is applied
+func LineTotal(line models.SaleLineDTO) float32 {
+	return RoundCents(line.UnitPrice*float32(line.Amount) - line.Discount)
+}
+
+// Totals returns the subtotal, the tax and the total of a sale with the given lines
+func (c Calculator) Totals(lines []models.SaleLineDTO) (subtotal, tax, total float32) {
+	for _, line := range lines {
+		subtotal += LineTotal(line)
+	}
+	subtotal = RoundCents(subtotal)
+	tax = RoundCents(subtotal * c.TaxRate)
+	total = RoundCents(subtotal + tax)
+	return subtotal, tax, total
+}
+
+// RoundCents rounds an amount of money to two decimals
+func RoundCents(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
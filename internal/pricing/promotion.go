@@ -0,0 +1,89 @@
+package pricing
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// ApplyPromotions sets on every line the discount of the best promotion that applies to it at the given time.
+//
+// products holds the catalog data of the products on the lines indexed by product id, it is needed to match
+// promotions scoped by brand or category.
+func ApplyPromotions(lines []models.SaleLineDTO, products map[int]models.Product, clientID int, promotions []models.Promotion, at time.Time) {
+	for i := range lines {
+		line := &lines[i]
+		product := products[line.ProductID]
+
+		for _, promotion := range promotions {
+			if !Applies(promotion, product, clientID, at) {
+				continue
+			}
+
+			discount := Discount(promotion, *line)
+			if discount > line.Discount {
+				line.Discount = discount
+				line.PromotionID = promotion.PromotionID
+			}
+		}
+	}
+}
+
+// Applies tells if a promotion is valid at the given time and its scope matches the product and client
+func Applies(promotion models.Promotion, product models.Product, clientID int, at time.Time) bool {
+	if !promotion.Active || at.Before(promotion.StartsAt) || !at.Before(promotion.EndsAt.AddDate(0, 0, 1)) {
+		return false
+	}
+
+	if promotion.ProductID != 0 && promotion.ProductID != product.ProductID {
+		return false
+	}
+
+	if promotion.Brand != "" && !strings.EqualFold(strings.TrimSpace(promotion.Brand), strings.TrimSpace(product.Brand)) {
+		return false
+	}
+
+	if promotion.CategoryID != 0 && !inCategory(promotion, product.Category.CategoryID) {
+		return false
+	}
+
+	if promotion.ClientID != 0 && promotion.ClientID != clientID {
+		return false
+	}
+
+	return true
+}
+
+// inCategory tells if a category is the category of a promotion or one nested under it
+func inCategory(promotion models.Promotion, categoryID int) bool {
+	if promotion.CategoryID == categoryID {
+		return true
+	}
+
+	for _, subcategory := range promotion.Subcategories {
+		if subcategory == categoryID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Discount returns the amount a promotion takes off a line
+func Discount(promotion models.Promotion, line models.SaleLineDTO) float32 {
+	gross := line.UnitPrice * float32(line.Amount)
+
+	switch promotion.Kind {
+	case models.PromotionPercent:
+		return RoundCents(gross * promotion.Percent / 100)
+	case models.PromotionBuyXGetY:
+		if promotion.Buy <= 0 || promotion.Free <= 0 {
+			return 0
+		}
+		freeUnits := line.Amount / (promotion.Buy + promotion.Free) * promotion.Free
+		return RoundCents(line.UnitPrice * float32(freeUnits))
+	}
+
+	return 0
+}
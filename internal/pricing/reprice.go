@@ -0,0 +1,33 @@
+package pricing
+
+import (
+	"math"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// Reprice moves a price by a percent or by an absolute amount and rounds the result by a rounding rule. Rounding
+// to ninety nine goes up to the closest price ending in .99, so a price never drops because of its rounding.
+//
+// A price moved to zero or below is returned without rounding, callers must reject it
+func Reprice(price float32, mode string, value float32, rounding string) float32 {
+	next := float64(price)
+	if mode == models.RepriceByPercent {
+		next *= 1 + float64(value)/100
+	} else {
+		next += float64(value)
+	}
+
+	if next <= 0 {
+		return RoundCents(float32(next))
+	}
+
+	switch rounding {
+	case models.RoundToPeso:
+		next = math.Round(next)
+	case models.RoundToNinetyNine:
+		next = math.Ceil(next+0.01) - 0.01
+	}
+
+	return RoundCents(float32(next))
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrProductNotFound is returned when an operation references a product that does not exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrSaleNotFound is returned when an operation references a sale or sale line that does not exist
+	ErrSaleNotFound = errors.New("sale not found")
+	// ErrSaleHasReturns is returned when trying to rewrite or delete a sale that already has returns
+	ErrSaleHasReturns = errors.New("sale has returns")
+	// ErrSaleFinalized is returned when trying to pay a sale that is already fully paid
+	ErrSaleFinalized = errors.New("sale already finalized")
+	// ErrSaleHasPayments is returned when trying to rewrite a sale that already received payments
+	ErrSaleHasPayments = errors.New("sale has payments")
+	// ErrManagerNotAuthorized is returned when a price override comes without the valid PIN of an active manager
+	ErrManagerNotAuthorized = errors.New("manager authorization rejected")
+	// ErrPaymentExceedsTotal is returned when a payment that can not give change goes over the sale total
+	ErrPaymentExceedsTotal = errors.New("payment exceeds sale total")
+	// ErrSessionNotFound is returned when an operation references a register session that does not exist
+	ErrSessionNotFound = errors.New("register session not found")
+	// ErrSessionClosed is returned when trying to operate over a register session that is already closed
+	ErrSessionClosed = errors.New("register session closed")
+	// ErrSessionAlreadyOpen is returned when a cashier tries to open a second register session
+	ErrSessionAlreadyOpen = errors.New("cashier already has an open register session")
+	// ErrQuoteNotFound is returned when an operation references a quote that does not exist
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteNotOpen is returned when trying to convert or cancel a quote that is expired, converted or cancelled
+	ErrQuoteNotOpen = errors.New("quote is not open")
+	// ErrClientNotFound is returned when an operation references a client that does not exist
+	ErrClientNotFound = errors.New("client not found")
+	// ErrClientWithoutTaxData is returned when invoicing a sale whose client has not registered its tax data
+	ErrClientWithoutTaxData = errors.New("client has no tax data")
+	// ErrSaleInvoiced is returned when trying to invoice again, rewrite or delete a sale that is already invoiced
+	ErrSaleInvoiced = errors.New("sale already invoiced")
+	// ErrInvoiceNotFound is returned when an operation references an invoice that does not exist
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvoiceStamped is returned when trying to stamp an invoice that was already stamped
+	ErrInvoiceStamped = errors.New("invoice already stamped")
+	// ErrProviderNotFound is returned when an operation references a provider that does not exist
+	ErrProviderNotFound = errors.New("provider not found")
+	// ErrPreferredProvider is returned when trying to unlink the preferred provider of a product
+	ErrPreferredProvider = errors.New("preferred provider can not be unlinked")
+	// ErrPurchaseOrderNotFound is returned when an operation references a purchase order that does not exist
+	ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+	// ErrPurchaseOrderStatus is returned when a purchase order can not go through an operation in its current status
+	ErrPurchaseOrderStatus = errors.New("operation not allowed in purchase order status")
+	// ErrPurchaseLineNotFound is returned when a receipt references a line that is not part of the purchase order
+	ErrPurchaseLineNotFound = errors.New("purchase order line not found")
+	// ErrReceiptExceedsOrder is returned when receiving more units than the ones pending in a purchase order line
+	ErrReceiptExceedsOrder = errors.New("receipt exceeds ordered quantity")
+	// ErrCountNotFound is returned when an operation references an inventory count that does not exist
+	ErrCountNotFound = errors.New("inventory count not found")
+	// ErrCountNotOpen is returned when trying to count, post or cancel an inventory count that is already closed
+	ErrCountNotOpen = errors.New("inventory count is not open")
+	// ErrCountLineNotFound is returned when counting a product that is not part of an inventory count
+	ErrCountLineNotFound = errors.New("product not in inventory count")
+	// ErrSKUTaken is returned when giving a product the SKU of another product
+	ErrSKUTaken = errors.New("sku already taken")
+	// ErrBarcodeTaken is returned when giving a product a barcode of another product
+	ErrBarcodeTaken = errors.New("barcode already taken")
+	// ErrVehicleNotFound is returned when an operation references a vehicle that does not exist
+	ErrVehicleNotFound = errors.New("vehicle not found")
+	// ErrVehicleExists is returned when saving a vehicle that is already in the catalog
+	ErrVehicleExists = errors.New("vehicle already exists")
+	// ErrCrossReferenceExists is returned when recording an equivalence a product already has
+	ErrCrossReferenceExists = errors.New("cross reference already exists")
+	// ErrSelfCrossReference is returned when recording a product as an equivalence of itself
+	ErrSelfCrossReference = errors.New("product cannot be its own cross reference")
+	// ErrBranchNotFound is returned when an operation references a branch that does not exist
+	ErrBranchNotFound = errors.New("branch not found")
+	// ErrBranchNameTaken is returned when saving a branch with the name of another one
+	ErrBranchNameTaken = errors.New("branch name already taken")
+	// ErrTransferNotFound is returned when an operation references a transfer that does not exist
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrTransferNotInTransit is returned when trying to receive or cancel a transfer that is no longer in transit
+	ErrTransferNotInTransit = errors.New("transfer is not in transit")
+	// ErrNegativePrice is returned when a price change would leave a product with a price of zero or below
+	ErrNegativePrice = errors.New("price at or below zero")
+	// ErrBelowMinimumMargin is returned when a bulk price change leaves products under the minimum margin of a markup
+	// rule that blocks it
+	ErrBelowMinimumMargin = errors.New("price below minimum margin")
+	// ErrCategoryNotFound is returned when an operation references a category that does not exist
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryNameTaken is returned when saving a category with the name of another one under the same parent
+	ErrCategoryNameTaken = errors.New("category name already taken")
+	// ErrCategoryCycle is returned when moving a category under itself or one of the categories under it
+	ErrCategoryCycle = errors.New("category can not be moved under itself")
+	// ErrCategoryInUse is returned when deleting a category that has products, or promotions applied on sales,
+	// without a category to reassign them to
+	ErrCategoryInUse = errors.New("category in use")
+	// ErrBrandNotFound is returned when an operation references a brand that does not exist, by id, name or alias
+	ErrBrandNotFound = errors.New("brand not found")
+	// ErrBrandNameTaken is returned when saving a brand with a name or alias another brand already has
+	ErrBrandNameTaken = errors.New("brand name already taken")
+	// ErrBrandInUse is returned when deleting a brand that has products without a brand to merge it into
+	ErrBrandInUse = errors.New("brand in use")
+	// ErrMarkupRuleExists is returned when saving a markup rule for a category, brand or provider that already has one
+	ErrMarkupRuleExists = errors.New("markup rule already exists")
+	// ErrReturnExceedsSale is returned when returning more units than the ones sold and not yet returned
+	ErrReturnExceedsSale = errors.New("return exceeds sold quantity")
+)
+
+// InsufficientStockError is returned when a product has not enough stock to fulfill an operation
+type InsufficientStockError struct {
+	ProductID int
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+// CreditLimitError is returned when a credit sale would take a client over its credit limit
+type CreditLimitError struct {
+	ClientID  int
+	Limit     float32
+	Balance   float32
+	Requested float32
+}
+
+func (e *CreditLimitError) Error() string {
+	return fmt.Sprintf("client %d over credit limit: limit %.2f, balance %.2f, requested %.2f", e.ClientID, e.Limit, e.Balance, e.Requested)
+}
@@ -0,0 +1,440 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// mainBranch branch created by the migration that introduced branches, operations that do not name a branch
+// happen at it
+const mainBranch = 1
+
+// GetAllBranches fetches all branches from database
+func (r *Repository) GetAllBranches() ([]models.Branch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	branches := []models.Branch{}
+	query := `SELECT id_sucursal, nombre, direccion FROM sucursal ORDER BY id_sucursal;`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b := models.Branch{}
+		err := rows.Scan(&b.BranchID, &b.Name, &b.Address)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return branches, nil
+}
+
+// InsertBranch inserts a branch without stock, returns its id
+func (r *Repository) InsertBranch(branch models.BranchDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = checkBranchNameFree(ctx, tx, branch.Name, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var branchID int
+	query := `INSERT INTO sucursal (nombre, direccion) VALUES ($1, $2) RETURNING id_sucursal;`
+	err = tx.QueryRowContext(ctx, query, branch.Name, branch.Address).Scan(&branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return branchID, nil
+}
+
+// UpdateBranch updates the name and address of a branch
+func (r *Repository) UpdateBranch(branchID int, branch models.BranchDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = checkBranchNameFree(ctx, tx, branch.Name, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE sucursal SET nombre = $1, direccion = $2 WHERE id_sucursal = $3;`
+	result, err := tx.ExecContext(ctx, query, branch.Name, branch.Address, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, tx.Commit()
+}
+
+// GetAllTransfers fetches all transfers from database with their lines, newest first
+func (r *Repository) GetAllTransfers() ([]models.Transfer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	transfers := []models.Transfer{}
+	query := `
+		SELECT
+			t.id_traspaso,
+			t.estado,
+			t.fecha_envio,
+			t.fecha_recepcion,
+			t.notas,
+			o.id_sucursal,
+			o.nombre,
+			d.id_sucursal,
+			d.nombre,
+			dt.cantidad,
+			p.id_producto,
+			p.clasificacion,
+			p.marca
+		FROM traspaso t
+		INNER JOIN sucursal o
+			ON o.id_sucursal = t.id_origen
+		INNER JOIN sucursal d
+			ON d.id_sucursal = t.id_destino
+		INNER JOIN detalle_traspaso dt
+			ON dt.id_traspaso = t.id_traspaso
+		INNER JOIN producto p
+			ON p.id_producto = dt.id_producto
+		ORDER BY t.id_traspaso DESC, dt.id_detalle_traspaso;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := models.Transfer{}
+		l := models.TransferLine{}
+		var receivedAt sql.NullTime
+		err := rows.Scan(
+			&t.TransferID, &t.Status, &t.SentAt, &receivedAt, &t.Notes,
+			&t.Origin.BranchID, &t.Origin.Name, &t.Destination.BranchID, &t.Destination.Name,
+			&l.Amount, &l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		last := len(transfers) - 1
+		if last >= 0 && transfers[last].TransferID == t.TransferID {
+			transfers[last].Lines = append(transfers[last].Lines, l)
+			continue
+		}
+
+		if receivedAt.Valid {
+			t.ReceivedAt = &receivedAt.Time
+		}
+		t.Lines = []models.TransferLine{l}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// InsertTransfer sends stock from a branch to another, its units leave the origin right away and stay in transit
+// until the destination receives them. Returns its id
+func (r *Repository) InsertTransfer(transfer models.TransferDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, branchID := range []int{transfer.OriginID, transfer.DestinationID} {
+		err = checkBranchExists(ctx, tx, branchID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var transferID int
+	query := `
+		INSERT INTO traspaso (id_origen, id_destino, estado, fecha_envio, notas)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4) RETURNING id_traspaso;
+	`
+	err = tx.QueryRowContext(ctx, query, transfer.OriginID, transfer.DestinationID, models.TransferInTransit, transfer.Notes).Scan(&transferID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementTransfer, transferReference(transferID), "")
+	if err != nil {
+		return 0, err
+	}
+
+	// Units leave the origin the same way they leave it on a sale
+	lines := make([]models.SaleLineDTO, 0, len(transfer.Lines))
+	for _, line := range transfer.Lines {
+		lines = append(lines, models.SaleLineDTO{ProductID: line.ProductID, Amount: line.Amount})
+	}
+
+	_, err = reserveStock(ctx, tx, transfer.OriginID, lines)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO detalle_traspaso (id_traspaso, id_producto, cantidad) VALUES ($1, $2, $3);`
+	for _, line := range transfer.Lines {
+		_, err = tx.ExecContext(ctx, query, transferID, line.ProductID, line.Amount)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return transferID, nil
+}
+
+// ReceiveTransfer adds the units of a transfer in transit to the stock of its destination
+func (r *Repository) ReceiveTransfer(transferID int) error {
+	return r.closeTransfer(transferID, models.TransferReceived)
+}
+
+// CancelTransfer gives the units of a transfer in transit back to the stock of its origin
+func (r *Repository) CancelTransfer(transferID int) error {
+	return r.closeTransfer(transferID, models.TransferCancelled)
+}
+
+// closeTransfer takes a transfer out of transit, received units enter the destination and cancelled ones go back
+// to the origin
+func (r *Repository) closeTransfer(transferID int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	var originID, destinationID int
+	query := `SELECT estado, id_origen, id_destino FROM traspaso WHERE id_traspaso = $1 FOR UPDATE;`
+	err = tx.QueryRowContext(ctx, query, transferID).Scan(&current, &originID, &destinationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrTransferNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if current != models.TransferInTransit {
+		return repository.ErrTransferNotInTransit
+	}
+
+	branchID := destinationID
+	if status == models.TransferCancelled {
+		branchID = originID
+	}
+
+	query = `
+		SELECT id_producto, SUM(cantidad)
+		FROM detalle_traspaso
+		WHERE id_traspaso = $1
+		GROUP BY id_producto;
+	`
+	rows, err := tx.QueryContext(ctx, query, transferID)
+	if err != nil {
+		return err
+	}
+
+	amounts := make(map[int]int)
+	var products []int
+	for rows.Next() {
+		var productID, amount int
+		err := rows.Scan(&productID, &amount)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, productID)
+		amounts[productID] = amount
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementTransfer, transferReference(transferID), "")
+	if err != nil {
+		return err
+	}
+
+	// Same lock order as the sales, so a transfer can not deadlock with them
+	sort.Ints(products)
+	for _, productID := range products {
+		_, err = tx.ExecContext(ctx, `SELECT 1 FROM producto WHERE id_producto = $1 FOR UPDATE;`, productID)
+		if err != nil {
+			return err
+		}
+
+		err = addBranchStock(ctx, tx, branchID, productID, amounts[productID])
+		if err != nil {
+			return err
+		}
+	}
+
+	query = `UPDATE traspaso SET estado = $1, fecha_recepcion = CURRENT_TIMESTAMP WHERE id_traspaso = $2;`
+	if status == models.TransferCancelled {
+		query = `UPDATE traspaso SET estado = $1 WHERE id_traspaso = $2;`
+	}
+	_, err = tx.ExecContext(ctx, query, status, transferID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryBranchStock reads the stock of a product, or of every product when the id is 0, at every branch it has been
+// at and its units in transit, both indexed by product id
+func queryBranchStock(ctx context.Context, tx *sql.Tx, productID int) (map[int][]models.BranchStock, map[int]int, error) {
+	query := `
+		SELECT e.id_producto, s.id_sucursal, s.nombre, e.stock
+		FROM existencia e
+		INNER JOIN sucursal s
+			ON s.id_sucursal = e.id_sucursal
+		WHERE $1 = 0 OR e.id_producto = $1
+		ORDER BY e.id_producto, s.id_sucursal;
+	`
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	stock := make(map[int][]models.BranchStock)
+	for rows.Next() {
+		var productID int
+		s := models.BranchStock{}
+		err := rows.Scan(&productID, &s.BranchID, &s.Name, &s.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		stock[productID] = append(stock[productID], s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	query = `
+		SELECT d.id_producto, SUM(d.cantidad)
+		FROM detalle_traspaso d
+		INNER JOIN traspaso t
+			ON t.id_traspaso = d.id_traspaso
+		WHERE t.estado = $1 AND ($2 = 0 OR d.id_producto = $2)
+		GROUP BY d.id_producto;
+	`
+	rows, err = tx.QueryContext(ctx, query, models.TransferInTransit, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	inTransit := make(map[int]int)
+	for rows.Next() {
+		var productID, amount int
+		err := rows.Scan(&productID, &amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		inTransit[productID] = amount
+	}
+
+	return stock, inTransit, rows.Err()
+}
+
+// branchOrMain returns the branch given or the main one when none was given
+func branchOrMain(branchID int) int {
+	if branchID == 0 {
+		return mainBranch
+	}
+
+	return branchID
+}
+
+// checkBranchExists fails with repository.ErrBranchNotFound when a branch does not exist
+func checkBranchExists(ctx context.Context, tx *sql.Tx, branchID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM sucursal WHERE id_sucursal = $1);`
+	err := tx.QueryRowContext(ctx, query, branchID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return repository.ErrBranchNotFound
+	}
+
+	return nil
+}
+
+// checkBranchNameFree fails with repository.ErrBranchNameTaken when another branch already has the name
+func checkBranchNameFree(ctx context.Context, tx *sql.Tx, name string, branchID int) error {
+	var taken bool
+	query := `SELECT EXISTS (SELECT 1 FROM sucursal WHERE nombre = $1 AND id_sucursal <> $2);`
+	err := tx.QueryRowContext(ctx, query, name, branchID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return repository.ErrBranchNameTaken
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllBrands fetches every brand with its aliases and how many products it has, ordered by name
+func (r *Repository) GetAllBrands() ([]models.Brand, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT m.id_marca, m.nombre, COUNT(p.id_producto)
+		FROM marca m
+		LEFT JOIN producto p
+			ON p.id_marca = m.id_marca
+		GROUP BY m.id_marca, m.nombre
+		ORDER BY LOWER(m.nombre);
+	`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	brands := []models.Brand{}
+	for rows.Next() {
+		b := models.Brand{Aliases: []string{}}
+		err := rows.Scan(&b.BrandID, &b.Name, &b.Products)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `SELECT id_marca, alias FROM alias_marca ORDER BY LOWER(alias);`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int][]string)
+	for rows.Next() {
+		var brandID int
+		var alias string
+		err := rows.Scan(&brandID, &alias)
+		if err != nil {
+			return nil, err
+		}
+		aliases[brandID] = append(aliases[brandID], alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range brands {
+		if aliases[brands[i].BrandID] != nil {
+			brands[i].Aliases = aliases[brands[i].BrandID]
+		}
+	}
+
+	return brands, tx.Commit()
+}
+
+// ResolveBrand finds a brand by id, or by its name or one of its aliases ignoring case and surrounding spaces when
+// the id is 0
+func (r *Repository) ResolveBrand(brandID int, name string) (models.Brand, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	brand := models.Brand{}
+	query := `
+		SELECT m.id_marca, m.nombre
+		FROM marca m
+		LEFT JOIN alias_marca a
+			ON a.id_marca = m.id_marca
+		WHERE m.id_marca = $1
+			OR ($1 = 0 AND (LOWER(m.nombre) = LOWER($2) OR LOWER(a.alias) = LOWER($2)))
+		LIMIT 1;
+	`
+	err := r.db.QueryRowContext(ctx, query, brandID, strings.TrimSpace(name)).Scan(&brand.BrandID, &brand.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return brand, repository.ErrBrandNotFound
+	}
+
+	return brand, err
+}
+
+// InsertBrand inserts a brand with its aliases, returns its id
+func (r *Repository) InsertBrand(brand models.BrandDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Names and aliases are unique across both tables, which no index can guarantee, so brands are edited one at a time
+	_, err = tx.ExecContext(ctx, `LOCK TABLE marca IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return 0, err
+	}
+
+	name := strings.TrimSpace(brand.Name)
+	err = checkBrandNamesFree(ctx, tx, append([]string{name}, brand.Aliases...), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var brandID int
+	query := `INSERT INTO marca (nombre) VALUES ($1) RETURNING id_marca;`
+	err = tx.QueryRowContext(ctx, query, name).Scan(&brandID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertBrandAliases(ctx, tx, brandID, name, brand.Aliases)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return brandID, nil
+}
+
+// UpdateBrand renames a brand and replaces its aliases, its products, promotions and markup rules take the new name
+func (r *Repository) UpdateBrand(brandID int, brand models.BrandDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `LOCK TABLE marca IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return 0, err
+	}
+
+	name := strings.TrimSpace(brand.Name)
+	err = checkBrandNamesFree(ctx, tx, append([]string{name}, brand.Aliases...), brandID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE marca SET nombre = $1 WHERE id_marca = $2;`, name, brandID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rows == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM alias_marca WHERE id_marca = $1;`, brandID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertBrandAliases(ctx, tx, brandID, name, brand.Aliases)
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// DeleteBrand deletes a brand. Merging it into another brand moves its products to that one and keeps its name and
+// aliases as aliases of it, its promotions and markup rules take the name of that brand too; a markup rule is
+// dropped when that brand already has one.
+//
+// Without a brand to merge it into, a brand with products can not be deleted.
+func (r *Repository) DeleteBrand(brandID, mergeInto int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `LOCK TABLE marca IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return 0, err
+	}
+
+	var name string
+	err = tx.QueryRowContext(ctx, `SELECT nombre FROM marca WHERE id_marca = $1;`, brandID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if mergeInto != 0 {
+		var target string
+		err = tx.QueryRowContext(ctx, `SELECT nombre FROM marca WHERE id_marca = $1;`, mergeInto).Scan(&target)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, repository.ErrBrandNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE producto SET id_marca = $1 WHERE id_marca = $2;`, mergeInto, brandID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE alias_marca SET id_marca = $1 WHERE id_marca = $2;`, mergeInto, brandID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO alias_marca (id_marca, alias) VALUES ($1, $2);`, mergeInto, name)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE promocion SET marca = $1 WHERE LOWER(marca) = LOWER($2);`, target, name)
+		if err != nil {
+			return 0, err
+		}
+
+		query := `
+			DELETE FROM regla_margen
+			WHERE LOWER(marca) = LOWER($2) AND EXISTS (SELECT 1 FROM regla_margen WHERE LOWER(marca) = LOWER($1));
+		`
+		_, err = tx.ExecContext(ctx, query, target, name)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE regla_margen SET marca = $1 WHERE LOWER(marca) = LOWER($2);`, target, name)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var inUse bool
+	query := `SELECT EXISTS (SELECT 1 FROM producto WHERE id_marca = $1);`
+	err = tx.QueryRowContext(ctx, query, brandID).Scan(&inUse)
+	if err != nil {
+		return 0, err
+	}
+
+	if inUse {
+		return 0, repository.ErrBrandInUse
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM marca WHERE id_marca = $1;`, brandID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// insertBrandAliases inserts the aliases of a brand, the ones repeated or equal to its name are skipped
+func insertBrandAliases(ctx context.Context, tx *sql.Tx, brandID int, name string, aliases []string) error {
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if strings.EqualFold(alias, name) {
+			continue
+		}
+
+		query := `INSERT INTO alias_marca (id_marca, alias) VALUES ($1, $2) ON CONFLICT (LOWER(alias)) DO NOTHING;`
+		_, err := tx.ExecContext(ctx, query, brandID, alias)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBrandNamesFree fails with repository.ErrBrandNameTaken when a brand other than the given one has any of the
+// names as its name or as an alias, ignoring case and surrounding spaces
+func checkBrandNamesFree(ctx context.Context, tx *sql.Tx, names []string, brandID int) error {
+	for _, name := range names {
+		var taken bool
+		query := `
+			SELECT EXISTS (SELECT 1 FROM marca WHERE LOWER(nombre) = LOWER($1) AND id_marca <> $2)
+				OR EXISTS (SELECT 1 FROM alias_marca WHERE LOWER(alias) = LOWER($1) AND id_marca <> $2);
+		`
+		err := tx.QueryRowContext(ctx, query, strings.TrimSpace(name), brandID).Scan(&taken)
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			return repository.ErrBrandNameTaken
+		}
+	}
+
+	return nil
+}
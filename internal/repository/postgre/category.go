@@ -0,0 +1,249 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// InsertCategory inserts a category under its parent, returns its id
+func (r *Repository) InsertCategory(category models.CategoryDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	name := strings.TrimSpace(category.Name)
+	if category.ParentID != 0 {
+		err = checkCategoryExists(ctx, tx, category.ParentID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = checkCategoryNameFree(ctx, tx, name, category.ParentID, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var categoryID int
+	query := `INSERT INTO categoria (nombre_categoria, id_padre) VALUES ($1, NULLIF($2, 0)) RETURNING id_categoria;`
+	err = tx.QueryRowContext(ctx, query, name, category.ParentID).Scan(&categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return categoryID, nil
+}
+
+// UpdateCategory renames a category and moves it under another parent together with every category under it
+func (r *Repository) UpdateCategory(categoryID int, category models.CategoryDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Two moves checked at the same time could still close a cycle between them, so the tree is edited one at a time
+	_, err = tx.ExecContext(ctx, `LOCK TABLE categoria IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return 0, err
+	}
+
+	name := strings.TrimSpace(category.Name)
+	if category.ParentID != 0 {
+		err = checkCategoryExists(ctx, tx, category.ParentID)
+		if err != nil {
+			return 0, err
+		}
+
+		var cycle bool
+		query := `SELECT EXISTS (SELECT 1 FROM subcategorias($1) WHERE id_categoria = $2);`
+		err = tx.QueryRowContext(ctx, query, categoryID, category.ParentID).Scan(&cycle)
+		if err != nil {
+			return 0, err
+		}
+
+		if cycle {
+			return 0, repository.ErrCategoryCycle
+		}
+	}
+
+	err = checkCategoryNameFree(ctx, tx, name, category.ParentID, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE categoria SET nombre_categoria = $1, id_padre = NULLIF($2, 0) WHERE id_categoria = $3;`
+	result, err := tx.ExecContext(ctx, query, name, category.ParentID, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// DeleteCategory deletes a category, the categories under it move up to its parent.
+//
+// Its products and promotions move to the category to reassign them to. Without one, a category with products or
+// with promotions applied on sales can not be deleted; its markup rule is deleted with it.
+func (r *Repository) DeleteCategory(categoryID, reassignTo int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `LOCK TABLE categoria IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return 0, err
+	}
+
+	var parentID int
+	query := `SELECT COALESCE(id_padre, 0) FROM categoria WHERE id_categoria = $1;`
+	err = tx.QueryRowContext(ctx, query, categoryID).Scan(&parentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if reassignTo != 0 {
+		err = checkCategoryExists(ctx, tx, reassignTo)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE producto SET id_categoria = $1 WHERE id_categoria = $2;`, reassignTo, categoryID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE promocion SET id_categoria = $1 WHERE id_categoria = $2;`, reassignTo, categoryID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var inUse bool
+	query = `
+		SELECT
+			EXISTS (SELECT 1 FROM producto WHERE id_categoria = $1)
+			OR EXISTS (
+				SELECT 1
+				FROM promocion pm
+				INNER JOIN detalle_venta d
+					ON d.id_promocion = pm.id_promocion
+				WHERE pm.id_categoria = $1
+			);
+	`
+	err = tx.QueryRowContext(ctx, query, categoryID).Scan(&inUse)
+	if err != nil {
+		return 0, err
+	}
+
+	if inUse {
+		return 0, repository.ErrCategoryInUse
+	}
+
+	// The categories moving up can not take a name their new siblings already have
+	var taken bool
+	query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM categoria c
+			INNER JOIN categoria s
+				ON LOWER(s.nombre_categoria) = LOWER(c.nombre_categoria)
+			WHERE c.id_padre = $1 AND s.id_categoria <> $1 AND COALESCE(s.id_padre, 0) = $2
+		);
+	`
+	err = tx.QueryRowContext(ctx, query, categoryID, parentID).Scan(&taken)
+	if err != nil {
+		return 0, err
+	}
+
+	if taken {
+		return 0, repository.ErrCategoryNameTaken
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE categoria SET id_padre = NULLIF($1, 0) WHERE id_padre = $2;`, parentID, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM categoria WHERE id_categoria = $1;`, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// checkCategoryNameFree fails with repository.ErrCategoryNameTaken when a category other than the given one has
+// the name under the same parent, ignoring case
+func checkCategoryNameFree(ctx context.Context, tx *sql.Tx, name string, parentID, categoryID int) error {
+	var taken bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM categoria
+			WHERE LOWER(nombre_categoria) = LOWER($1) AND COALESCE(id_padre, 0) = $2 AND id_categoria <> $3
+		);
+	`
+	err := tx.QueryRowContext(ctx, query, name, parentID, categoryID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return repository.ErrCategoryNameTaken
+	}
+
+	return nil
+}
+
+// checkCategoryExists fails with repository.ErrCategoryNotFound when a category does not exist
+func checkCategoryExists(ctx context.Context, tx *sql.Tx, categoryID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM categoria WHERE id_categoria = $1);`
+	err := tx.QueryRowContext(ctx, query, categoryID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return repository.ErrCategoryNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// LookupProducts fetches the products whose SKU, part number, OEM number or one of its barcodes is exactly the
+// given code, ignoring case and surrounding spaces
+func (r *Repository) LookupProducts(code string) ([]models.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	code = normalizeCode(code)
+	if code == "" {
+		return []models.Product{}, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	products, err := queryProducts(ctx, tx, models.ProductFilter{Code: code})
+	if err != nil {
+		return nil, err
+	}
+
+	return products, tx.Commit()
+}
+
+// SetProductCodes replaces the SKU, part number, OEM number and barcodes of a product, an empty code removes it
+func (r *Repository) SetProductCodes(productID int, codes models.ProductCodesDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id_producto FROM producto WHERE id_producto = $1 FOR UPDATE;`
+	err = tx.QueryRowContext(ctx, query, productID).Scan(&productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	sku := normalizeCode(codes.SKU)
+	if sku != "" {
+		var taken bool
+		query = `SELECT EXISTS (SELECT 1 FROM producto WHERE sku = $1 AND id_producto <> $2);`
+		err = tx.QueryRowContext(ctx, query, sku, productID).Scan(&taken)
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			return repository.ErrSKUTaken
+		}
+	}
+
+	query = `
+		UPDATE producto
+		SET sku = NULLIF($1, ''), numero_parte = NULLIF($2, ''), numero_oem = NULLIF($3, '')
+		WHERE id_producto = $4;
+	`
+	_, err = tx.ExecContext(ctx, query, sku, normalizeCode(codes.PartNumber), normalizeCode(codes.OEMNumber), productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM codigo_barras WHERE id_producto = $1;`, productID)
+	if err != nil {
+		return err
+	}
+
+	for _, barcode := range codes.Barcodes {
+		barcode = normalizeCode(barcode)
+
+		var taken bool
+		query = `SELECT EXISTS (SELECT 1 FROM codigo_barras WHERE codigo = $1 AND id_producto <> $2);`
+		err = tx.QueryRowContext(ctx, query, barcode, productID).Scan(&taken)
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			return repository.ErrBarcodeTaken
+		}
+
+		query = `INSERT INTO codigo_barras (codigo, id_producto) VALUES ($1, $2) ON CONFLICT (codigo) DO NOTHING;`
+		_, err = tx.ExecContext(ctx, query, barcode, productID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// queryBarcodes reads the barcodes of a product, or of every product when the id is 0, indexed by product id
+func queryBarcodes(ctx context.Context, tx *sql.Tx, productID int) (map[int][]string, error) {
+	query := `
+		SELECT id_producto, codigo
+		FROM codigo_barras
+		WHERE $1 = 0 OR id_producto = $1
+		ORDER BY id_producto, codigo;
+	`
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var barcode string
+		err := rows.Scan(&id, &barcode)
+		if err != nil {
+			return nil, err
+		}
+		barcodes[id] = append(barcodes[id], barcode)
+	}
+
+	return barcodes, rows.Err()
+}
+
+// normalizeCode gives a code the form it is stored with, so codes typed by hand match the scanned or stored ones
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
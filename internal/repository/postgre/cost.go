@@ -0,0 +1,310 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+)
+
+// GetInventoryValuation values the inventory owned at the end of a date by a costing method, an empty method means
+// the one the store uses.
+//
+// Layers are rebuilt from the consumptions made up to that date, so past dates give the value they had back then.
+func (r *Repository) GetInventoryValuation(date time.Time, method string) (models.InventoryValuation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if method == "" {
+		method = r.costing
+	}
+
+	valuation := models.InventoryValuation{Date: date, Method: method, Products: []models.ProductValuation{}}
+	query := `
+		SELECT p.id_producto, p.clasificacion, p.marca, k.cantidad, k.valor, COALESCE(h.costo, 0)
+		FROM (
+			SELECT
+				c.id_producto,
+				SUM(c.cantidad - COALESCE(u.cantidad, 0)) AS cantidad,
+				SUM((c.cantidad - COALESCE(u.cantidad, 0)) * c.costo_unitario) AS valor
+			FROM capa_costo c
+			LEFT JOIN (
+				SELECT id_capa, SUM(cantidad) AS cantidad
+				FROM consumo_capa
+				WHERE fecha < $1
+				GROUP BY id_capa
+			) u
+				ON u.id_capa = c.id_capa
+			WHERE c.fecha < $1
+			GROUP BY c.id_producto
+		) k
+		INNER JOIN producto p
+			ON p.id_producto = k.id_producto
+		LEFT JOIN LATERAL (
+			SELECT costo
+			FROM historial_costo_promedio
+			WHERE id_producto = k.id_producto AND fecha < $1
+			ORDER BY fecha DESC, id_historial DESC
+			LIMIT 1
+		) h
+			ON TRUE
+		WHERE k.cantidad > 0
+		ORDER BY p.id_producto;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, date.AddDate(0, 0, 1))
+	if err != nil {
+		return valuation, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fifoValue, averageCost float64
+		v := models.ProductValuation{}
+		err := rows.Scan(
+			&v.Product.ProductID, &v.Product.Classification, &v.Product.Brand, &v.Amount, &fifoValue, &averageCost,
+		)
+		if err != nil {
+			return valuation, err
+		}
+
+		value := fifoValue
+		if method == models.CostingWeightedAverage {
+			value = averageCost * float64(v.Amount)
+		}
+		v.Value = pricing.RoundCents(float32(value))
+		v.UnitCost = float32(value / float64(v.Amount))
+
+		valuation.Units += v.Amount
+		valuation.Total += v.Value
+		valuation.Products = append(valuation.Products, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return valuation, err
+	}
+	valuation.Total = pricing.RoundCents(valuation.Total)
+
+	return valuation, nil
+}
+
+// addCostLayer records units of a product that came in at a unit cost, blending them into its average cost
+func addCostLayer(ctx context.Context, tx *sql.Tx, productID, amount int, unitCost float32, reference string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	err := blendAverageCost(ctx, tx, productID, amount, unitCost)
+	if err != nil {
+		return err
+	}
+
+	return insertCostLayer(ctx, tx, productID, amount, unitCost, reference)
+}
+
+// consumeCost takes units of a product out of its oldest layers and returns their cost by the method the store
+// uses. A sale line consuming them is linked, lineID 0 means the units did not leave through a sale.
+//
+// Units beyond what the layers hold are valued at the average cost of the product.
+func (r *Repository) consumeCost(ctx context.Context, tx *sql.Tx, productID, amount, lineID int, reference string) (float32, error) {
+	var averageCost float64
+	query := `SELECT costo_promedio FROM producto WHERE id_producto = $1;`
+	err := tx.QueryRowContext(ctx, query, productID).Scan(&averageCost)
+	if err != nil {
+		return 0, err
+	}
+
+	type layer struct {
+		layerID   int
+		remaining int
+		unitCost  float64
+	}
+
+	query = `
+		SELECT id_capa, restante, costo_unitario
+		FROM capa_costo
+		WHERE id_producto = $1 AND restante > 0
+		ORDER BY fecha, id_capa
+		FOR UPDATE;
+	`
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	var layers []layer
+	for rows.Next() {
+		l := layer{}
+		err := rows.Scan(&l.layerID, &l.remaining, &l.unitCost)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var fifoCost float64
+	pending := amount
+	for _, l := range layers {
+		if pending == 0 {
+			break
+		}
+
+		taken := l.remaining
+		if taken > pending {
+			taken = pending
+		}
+
+		query = `UPDATE capa_costo SET restante = restante - $1 WHERE id_capa = $2;`
+		_, err = tx.ExecContext(ctx, query, taken, l.layerID)
+		if err != nil {
+			return 0, err
+		}
+
+		query = `
+			INSERT INTO consumo_capa (id_capa, id_detalle, cantidad, referencia)
+			VALUES ($1, NULLIF($2, 0), $3, $4);
+		`
+		_, err = tx.ExecContext(ctx, query, l.layerID, lineID, taken, reference)
+		if err != nil {
+			return 0, err
+		}
+
+		fifoCost += float64(taken) * l.unitCost
+		pending -= taken
+	}
+	fifoCost += float64(pending) * averageCost
+
+	if r.costing == models.CostingFIFO {
+		return pricing.RoundCents(float32(fifoCost)), nil
+	}
+
+	return pricing.RoundCents(float32(averageCost * float64(amount))), nil
+}
+
+// restoreLineCost gives back to the layers units of a sale line returned to stock, the layers it consumed last are
+// refilled first. It returns the part of the cost of the line the units carried.
+//
+// Units the layers did not cover when they were sold come back as a new layer at the unit cost of the line.
+func restoreLineCost(ctx context.Context, tx *sql.Tx, lineID, amount int, reference string) (float32, error) {
+	var productID, sold int
+	var lineCost float32
+	query := `SELECT id_producto, cantidad, costo FROM detalle_venta WHERE id_detalle = $1;`
+	err := tx.QueryRowContext(ctx, query, lineID).Scan(&productID, &sold, &lineCost)
+	if err != nil {
+		return 0, err
+	}
+
+	unitCost := lineCost / float32(sold)
+	err = blendAverageCost(ctx, tx, productID, amount, unitCost)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		SELECT c.id_capa, SUM(c.cantidad)
+		FROM consumo_capa c
+		INNER JOIN capa_costo k
+			ON k.id_capa = c.id_capa
+		WHERE c.id_detalle = $1
+		GROUP BY c.id_capa, k.fecha
+		HAVING SUM(c.cantidad) > 0
+		ORDER BY k.fecha DESC, c.id_capa DESC;
+	`
+	rows, err := tx.QueryContext(ctx, query, lineID)
+	if err != nil {
+		return 0, err
+	}
+
+	var layerIDs, consumed []int
+	for rows.Next() {
+		var layerID, units int
+		err := rows.Scan(&layerID, &units)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layerIDs = append(layerIDs, layerID)
+		consumed = append(consumed, units)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	pending := amount
+	for i, layerID := range layerIDs {
+		if pending == 0 {
+			break
+		}
+
+		given := consumed[i]
+		if given > pending {
+			given = pending
+		}
+
+		query = `UPDATE capa_costo SET restante = restante + $1 WHERE id_capa = $2;`
+		_, err = tx.ExecContext(ctx, query, given, layerID)
+		if err != nil {
+			return 0, err
+		}
+
+		query = `INSERT INTO consumo_capa (id_capa, id_detalle, cantidad, referencia) VALUES ($1, $2, $3, $4);`
+		_, err = tx.ExecContext(ctx, query, layerID, lineID, -given, reference)
+		if err != nil {
+			return 0, err
+		}
+		pending -= given
+	}
+
+	err = insertCostLayer(ctx, tx, productID, pending, unitCost, reference)
+	if err != nil {
+		return 0, err
+	}
+
+	return pricing.RoundCents(unitCost * float32(amount)), nil
+}
+
+// blendAverageCost weighs units coming in at a unit cost into the average cost of a product and keeps its history.
+// It must run before the units reach the layers
+func blendAverageCost(ctx context.Context, tx *sql.Tx, productID, amount int, unitCost float32) error {
+	query := `
+		UPDATE producto p
+		SET costo_promedio = (c.restante * p.costo_promedio + $2::numeric * $3::numeric) / (c.restante + $2::numeric)
+		FROM (SELECT COALESCE(SUM(restante), 0) AS restante FROM capa_costo WHERE id_producto = $1) c
+		WHERE p.id_producto = $1;
+	`
+	_, err := tx.ExecContext(ctx, query, productID, amount, unitCost)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO historial_costo_promedio (id_producto, costo)
+		SELECT id_producto, costo_promedio FROM producto WHERE id_producto = $1;
+	`
+	_, err = tx.ExecContext(ctx, query, productID)
+	return err
+}
+
+// insertCostLayer inserts a layer of units of a product at a unit cost, nothing is inserted without units
+func insertCostLayer(ctx context.Context, tx *sql.Tx, productID, amount int, unitCost float32, reference string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO capa_costo (id_producto, cantidad, restante, costo_unitario, referencia)
+		VALUES ($1, $2, $2, $3, $4);
+	`
+	_, err := tx.ExecContext(ctx, query, productID, amount, unitCost, reference)
+	return err
+}
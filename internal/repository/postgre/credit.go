@@ -0,0 +1,227 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// settledRefundsQuery sums the refunds of the returns over the sale v that settled what was owed on it instead of
+// being handed out in cash
+const settledRefundsQuery = `COALESCE((SELECT SUM(dv.reembolso - dv.efectivo) FROM devolucion dv WHERE dv.id_venta = v.id_venta), 0)`
+
+// openInvoicesQuery selects the credit sales of a client that are not fully paid, oldest first
+const openInvoicesQuery = `
+	SELECT
+		v.id_venta,
+		v.fecha,
+		CURRENT_DATE - v.fecha,
+		v.total,
+		COALESCE((SELECT SUM(pv.monto - pv.cambio) FROM pago_venta pv WHERE pv.id_venta = v.id_venta), 0),
+		` + settledRefundsQuery + `
+	FROM venta v
+	WHERE v.id_cliente = $1 AND v.a_credito AND v.estado = 'pending'
+	ORDER BY v.fecha, v.id_venta
+`
+
+// clientBalanceQuery sums what a client owes on its credit sales net of their returns, %s is replaced by the
+// expression holding the client id
+const clientBalanceQuery = `
+	COALESCE((
+		SELECT SUM(
+			v.total
+			- COALESCE((SELECT SUM(pv.monto - pv.cambio) FROM pago_venta pv WHERE pv.id_venta = v.id_venta), 0)
+			- ` + settledRefundsQuery + `
+		)
+		FROM venta v
+		WHERE v.id_cliente = %s AND v.a_credito AND v.estado = 'pending'
+	), 0)
+`
+
+// GetClientStatement fetches the open credit sales of a client with its balance split in aging buckets
+func (r *Repository) GetClientStatement(clientID int) (models.ClientStatement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	statement := models.ClientStatement{Invoices: []models.OpenInvoice{}}
+	c := &statement.Client
+
+	query := `
+		SELECT id_cliente, nombre_cliente, direccion_cliente, telefono_cliente, limite_credito
+		FROM cliente
+		WHERE id_cliente = $1;
+	`
+	err := r.db.QueryRowContext(ctx, query, clientID).Scan(&c.ClientID, &c.Name, &c.Address, &c.Phone, &c.CreditLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return statement, repository.ErrClientNotFound
+	}
+	if err != nil {
+		return statement, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, openInvoicesQuery, clientID)
+	if err != nil {
+		return statement, err
+	}
+	invoices, err := scanOpenInvoices(rows)
+	if err != nil {
+		return statement, err
+	}
+
+	for _, invoice := range invoices {
+		c.Balance += invoice.Balance
+
+		switch {
+		case invoice.Days <= 30:
+			statement.Aging.Current += invoice.Balance
+		case invoice.Days <= 60:
+			statement.Aging.Days60 += invoice.Balance
+		case invoice.Days <= 90:
+			statement.Aging.Days90 += invoice.Balance
+		default:
+			statement.Aging.Over90 += invoice.Balance
+		}
+	}
+	statement.Invoices = invoices
+	c.Balance = pricing.RoundCents(c.Balance)
+	statement.Available = pricing.RoundCents(c.CreditLimit - c.Balance)
+
+	return statement, nil
+}
+
+// ApplyClientPayment applies a payment of a client over its open credit sales starting from the oldest one,
+// returns the receipt of every sale that received part of the payment
+func (r *Repository) ApplyClientPayment(clientID int, payment models.ClientPaymentDTO) ([]models.SaleReceipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenSession(ctx, tx, payment.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = lockClientCredit(ctx, tx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, openInvoicesQuery+` FOR UPDATE OF v;`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := scanOpenInvoices(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance float32
+	for _, invoice := range invoices {
+		balance += invoice.Balance
+	}
+	if payment.Amount > pricing.RoundCents(balance) {
+		return nil, repository.ErrPaymentExceedsTotal
+	}
+
+	receipts := []models.SaleReceipt{}
+	remaining := payment.Amount
+	for _, invoice := range invoices {
+		if remaining <= 0 {
+			break
+		}
+
+		amount := invoice.Balance
+		if remaining < amount {
+			amount = remaining
+		}
+		remaining = pricing.RoundCents(remaining - amount)
+
+		receipt := models.SaleReceipt{SaleID: invoice.SaleID, Total: invoice.Total - invoice.Refunded, Paid: invoice.Paid}
+		tender := models.PaymentDTO{Method: payment.Method, Amount: amount, Reference: payment.Reference}
+		receipt, err = applyPayments(ctx, tx, payment.SessionID, receipt, []models.PaymentDTO{tender})
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// checkCredit locks the credit of a client and fails when adding amount to its balance goes over its limit
+func checkCredit(ctx context.Context, tx *sql.Tx, clientID int, amount float32) error {
+	limit, err := lockClientCredit(ctx, tx, clientID)
+	if err != nil {
+		return err
+	}
+
+	var balance float32
+	query := `SELECT ` + fmt.Sprintf(clientBalanceQuery, "$1") + `;`
+	err = tx.QueryRowContext(ctx, query, clientID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+
+	if pricing.RoundCents(balance+amount) > limit {
+		return &repository.CreditLimitError{
+			ClientID:  clientID,
+			Limit:     limit,
+			Balance:   balance,
+			Requested: amount,
+		}
+	}
+
+	return nil
+}
+
+// lockClientCredit locks a client row so its credit sales and payments are serialized, returns its credit limit
+func lockClientCredit(ctx context.Context, tx *sql.Tx, clientID int) (float32, error) {
+	var limit float32
+	query := `SELECT limite_credito FROM cliente WHERE id_cliente = $1 FOR UPDATE;`
+	err := tx.QueryRowContext(ctx, query, clientID).Scan(&limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrClientNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return limit, nil
+}
+
+// scanOpenInvoices reads every invoice of rows selected with openInvoicesQuery and closes them
+func scanOpenInvoices(rows *sql.Rows) ([]models.OpenInvoice, error) {
+	defer rows.Close()
+
+	invoices := []models.OpenInvoice{}
+	for rows.Next() {
+		i := models.OpenInvoice{}
+		err := rows.Scan(&i.SaleID, &i.Date, &i.Days, &i.Total, &i.Paid, &i.Refunded)
+		if err != nil {
+			return nil, err
+		}
+		i.Balance = pricing.RoundCents(i.Total - i.Paid - i.Refunded)
+		invoices = append(invoices, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
@@ -0,0 +1,222 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetProductCrossReferences fetches every part a product can be replaced with, in stock or not
+func (r *Repository) GetProductCrossReferences(productID int) ([]models.CrossReference, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	references, err := queryCrossReferences(ctx, tx, productID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if references[productID] == nil {
+		return []models.CrossReference{}, tx.Commit()
+	}
+
+	return references[productID], tx.Commit()
+}
+
+// InsertCrossReference records a part a product can be replaced with, returns the id of the equivalence
+func (r *Repository) InsertCrossReference(productID int, reference models.CrossReferenceDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if reference.EquivalentID == productID {
+		return 0, repository.ErrSelfCrossReference
+	}
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	partNumber := normalizeCode(reference.PartNumber)
+	partBrand := strings.TrimSpace(reference.PartBrand)
+
+	// Exact and alternative equivalences already recorded from the other product count as the same one
+	var exists bool
+	if reference.EquivalentID != 0 {
+		err = checkProductExists(ctx, tx, reference.EquivalentID)
+		if err != nil {
+			return 0, err
+		}
+
+		query := `
+			SELECT EXISTS (
+				SELECT 1
+				FROM equivalencia
+				WHERE (id_producto = $1 AND id_equivalente = $2)
+					OR (id_producto = $2 AND id_equivalente = $1 AND tipo <> $3 AND $4 <> $3)
+			);
+		`
+		err = tx.QueryRowContext(ctx, query,
+			productID,
+			reference.EquivalentID,
+			models.EquivalenceSuperseded,
+			reference.Kind,
+		).Scan(&exists)
+	} else {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM equivalencia WHERE id_producto = $1 AND numero_externo = $2 AND marca_externa = $3
+			);
+		`
+		err = tx.QueryRowContext(ctx, query, productID, partNumber, partBrand).Scan(&exists)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return 0, repository.ErrCrossReferenceExists
+	}
+
+	var crossReferenceID int
+	query := `
+		INSERT INTO equivalencia (id_producto, id_equivalente, numero_externo, marca_externa, tipo, notas)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, ''), $4, $5, $6) RETURNING id_equivalencia;
+	`
+	err = tx.QueryRowContext(ctx, query,
+		productID,
+		reference.EquivalentID,
+		partNumber,
+		partBrand,
+		reference.Kind,
+		strings.TrimSpace(reference.Notes),
+	).Scan(&crossReferenceID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return crossReferenceID, nil
+}
+
+// DeleteCrossReference removes an equivalence of a product, whichever of both products it was recorded from
+func (r *Repository) DeleteCrossReference(productID, crossReferenceID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		DELETE FROM equivalencia
+		WHERE id_equivalencia = $1 AND (id_producto = $2 OR id_equivalente = $2);
+	`
+	result, err := r.db.ExecContext(ctx, query, crossReferenceID, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// queryCrossReferences reads the parts a product, or every product when the id is 0, can be replaced with indexed
+// by product id. Exact equivalences come first, then the products that supersede it and then the alternatives,
+// the ones with the most stock first.
+//
+// When only the ones in stock are wanted, part numbers of other brands are left out since they are not sold here.
+func queryCrossReferences(ctx context.Context, tx *sql.Tx, productID int, inStock bool) (map[int][]models.CrossReference, error) {
+	query := `
+		SELECT
+			x.propietario,
+			e.id_equivalencia,
+			e.tipo,
+			e.notas,
+			COALESCE(e.numero_externo, ''),
+			e.marca_externa,
+			p.id_producto,
+			p.clasificacion,
+			p.marca,
+			p.precio_publico,
+			p.stock
+		FROM (
+			SELECT id_equivalencia, id_producto AS propietario, id_equivalente AS equivalente
+			FROM equivalencia
+			UNION ALL
+			SELECT id_equivalencia, id_equivalente, id_producto
+			FROM equivalencia
+			WHERE id_equivalente IS NOT NULL AND tipo <> $1
+		) x
+		INNER JOIN equivalencia e
+			ON e.id_equivalencia = x.id_equivalencia
+		LEFT JOIN producto p
+			ON p.id_producto = x.equivalente
+		WHERE ($2 = 0 OR x.propietario = $2) AND (NOT $3 OR p.stock > 0)
+		ORDER BY
+			x.propietario,
+			CASE e.tipo WHEN $4 THEN 0 WHEN $1 THEN 1 ELSE 2 END,
+			p.stock DESC NULLS LAST,
+			e.id_equivalencia;
+	`
+
+	rows, err := tx.QueryContext(ctx, query,
+		models.EquivalenceSuperseded,
+		productID,
+		inStock,
+		models.EquivalenceExact,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	references := make(map[int][]models.CrossReference)
+	for rows.Next() {
+		var owner int
+		var equivalentID sql.NullInt64
+		var classification, brand sql.NullString
+		var price sql.NullFloat64
+		var stock sql.NullInt64
+		c := models.CrossReference{}
+		err := rows.Scan(
+			&owner, &c.CrossReferenceID, &c.Kind, &c.Notes, &c.PartNumber, &c.PartBrand,
+			&equivalentID, &classification, &brand, &price, &stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if equivalentID.Valid {
+			c.Product = &models.Product{
+				ProductID:      int(equivalentID.Int64),
+				Classification: classification.String,
+				Brand:          brand.String,
+				PublicPrice:    float32(price.Float64),
+				Amount:         int(stock.Int64),
+			}
+		}
+		references[owner] = append(references[owner], c)
+	}
+
+	return references, rows.Err()
+}
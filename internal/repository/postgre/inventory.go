@@ -0,0 +1,472 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllInventoryCounts fetches all inventory counts from database without their lines, newest first
+func (r *Repository) GetAllInventoryCounts() ([]models.InventoryCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	counts := []models.InventoryCount{}
+	query := `
+		SELECT
+			c.id_conteo,
+			c.descripcion,
+			c.tipo,
+			c.estado,
+			c.id_sucursal,
+			COALESCE(c.motivo, ''),
+			c.fecha_inicio,
+			c.fecha_cierre,
+			COUNT(d.id_detalle_conteo),
+			COUNT(d.contado),
+			COUNT(*) FILTER (WHERE d.contado <> d.esperado)
+		FROM conteo_inventario c
+		LEFT JOIN detalle_conteo d
+			ON d.id_conteo = c.id_conteo
+		GROUP BY c.id_conteo
+		ORDER BY c.id_conteo DESC;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := models.InventoryCount{}
+		var closedAt sql.NullTime
+		err := rows.Scan(
+			&c.CountID, &c.Description, &c.Kind, &c.Status, &c.BranchID, &c.Reason, &c.StartedAt, &closedAt,
+			&c.Products, &c.Counted, &c.Variances,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if closedAt.Valid {
+			c.ClosedAt = &closedAt.Time
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetInventoryCount fetches an inventory count with the variance of every product, the cost of a variance is
+// valued at the provider price of the product
+func (r *Repository) GetInventoryCount(countID int) (models.InventoryCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	c := models.InventoryCount{CountID: countID}
+	var closedAt sql.NullTime
+	query := `
+		SELECT descripcion, tipo, estado, id_sucursal, COALESCE(motivo, ''), fecha_inicio, fecha_cierre
+		FROM conteo_inventario
+		WHERE id_conteo = $1;
+	`
+	err := r.db.QueryRowContext(ctx, query, countID).Scan(
+		&c.Description, &c.Kind, &c.Status, &c.BranchID, &c.Reason, &c.StartedAt, &closedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, repository.ErrCountNotFound
+	}
+	if err != nil {
+		return c, err
+	}
+	if closedAt.Valid {
+		c.ClosedAt = &closedAt.Time
+	}
+
+	query = `
+		SELECT
+			d.esperado,
+			d.contado,
+			d.ajuste,
+			p.id_producto,
+			p.clasificacion,
+			p.marca,
+			p.precio_proveedor
+		FROM detalle_conteo d
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		WHERE d.id_conteo = $1
+		ORDER BY p.clasificacion, p.marca, p.id_producto;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, countID)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+
+	c.Lines = []models.InventoryCountLine{}
+	for rows.Next() {
+		l := models.InventoryCountLine{}
+		var counted, adjustment sql.NullInt64
+		err := rows.Scan(
+			&l.Expected, &counted, &adjustment,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand, &l.Product.ProviderPrice,
+		)
+		if err != nil {
+			return c, err
+		}
+
+		c.Products++
+		if counted.Valid {
+			units := int(counted.Int64)
+			l.Counted = &units
+			l.Variance = units - l.Expected
+			l.VarianceCost = float32(l.Variance) * l.Product.ProviderPrice
+			c.Counted++
+		}
+		if l.Variance != 0 {
+			c.Variances++
+		}
+		if adjustment.Valid {
+			units := int(adjustment.Int64)
+			l.Adjustment = &units
+		}
+		c.Lines = append(c.Lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// InsertInventoryCount opens an inventory count of a branch taking a snapshot of the current stock of its products
+// there, a full count takes every product. Returns its id
+func (r *Repository) InsertInventoryCount(count models.InventoryCountDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	branchID := branchOrMain(count.BranchID)
+	err = checkBranchExists(ctx, tx, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	var countID int
+	query := `
+		INSERT INTO conteo_inventario (descripcion, id_sucursal, tipo, estado, fecha_inicio)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP) RETURNING id_conteo;
+	`
+	err = tx.QueryRowContext(ctx, query, count.Description, branchID, count.Kind, models.CountOpen).Scan(&countID)
+	if err != nil {
+		return 0, err
+	}
+
+	if count.Kind == models.CountFull {
+		query = `
+			INSERT INTO detalle_conteo (id_conteo, id_producto, esperado)
+			SELECT $1, p.id_producto, COALESCE(e.stock, 0)
+			FROM producto p
+			LEFT JOIN existencia e
+				ON e.id_producto = p.id_producto AND e.id_sucursal = $2;
+		`
+		_, err = tx.ExecContext(ctx, query, countID, branchID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if count.Kind == models.CountCycle && count.CategoryID != 0 {
+		query = `
+			INSERT INTO detalle_conteo (id_conteo, id_producto, esperado)
+			SELECT $1, p.id_producto, COALESCE(e.stock, 0)
+			FROM producto p
+			LEFT JOIN existencia e
+				ON e.id_producto = p.id_producto AND e.id_sucursal = $2
+			WHERE p.id_categoria IN (SELECT id_categoria FROM subcategorias($3))
+			ON CONFLICT (id_conteo, id_producto) DO NOTHING;
+		`
+		_, err = tx.ExecContext(ctx, query, countID, branchID, count.CategoryID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if count.Kind == models.CountCycle {
+		query = `
+			INSERT INTO detalle_conteo (id_conteo, id_producto, esperado)
+			SELECT $1, p.id_producto, COALESCE(e.stock, 0)
+			FROM producto p
+			LEFT JOIN existencia e
+				ON e.id_producto = p.id_producto AND e.id_sucursal = $2
+			WHERE p.id_producto = $3
+			ON CONFLICT (id_conteo, id_producto) DO NOTHING;
+		`
+		for _, productID := range count.ProductIDs {
+			err = checkProductExists(ctx, tx, productID)
+			if err != nil {
+				return 0, err
+			}
+
+			_, err = tx.ExecContext(ctx, query, countID, branchID, productID)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var products int
+	query = `SELECT COUNT(*) FROM detalle_conteo WHERE id_conteo = $1;`
+	err = tx.QueryRowContext(ctx, query, countID).Scan(&products)
+	if err != nil {
+		return 0, err
+	}
+
+	if products == 0 {
+		return 0, repository.ErrProductNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return countID, nil
+}
+
+// RecordCountEntries stores the units counted of some products of an open inventory count. Entries of the same
+// product are added up inside a batch
+func (r *Repository) RecordCountEntries(countID int, entries models.CountEntriesDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenCount(ctx, tx, countID)
+	if err != nil {
+		return err
+	}
+
+	counted := make(map[int]int)
+	var products []int
+	for _, entry := range entries.Lines {
+		if _, ok := counted[entry.ProductID]; !ok {
+			products = append(products, entry.ProductID)
+		}
+		counted[entry.ProductID] += entry.Counted
+	}
+
+	query := `
+		UPDATE detalle_conteo
+		SET contado = CASE WHEN $1 THEN COALESCE(contado, 0) + $2 ELSE $2 END
+		WHERE id_conteo = $3 AND id_producto = $4;
+	`
+	for _, productID := range products {
+		result, err := tx.ExecContext(ctx, query, entries.Accumulate, counted[productID], countID, productID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return repository.ErrCountLineNotFound
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PostInventoryCount closes an inventory count adjusting the stock of the approved products at its branch by their
+// variance, returns the number of products adjusted.
+//
+// The variance is added to the current stock instead of overwriting it, so the sales and receipts made while the
+// store was being counted are kept. Only the given products are adjusted unless the approval explicitly approves
+// all of them, products not counted or not approved are left as they are. A shortage larger than the current stock
+// of a product fails the whole count with an InsufficientStockError naming it.
+func (r *Repository) PostInventoryCount(countID int, approval models.PostCountDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	branchID, err := lockOpenCount(ctx, tx, countID)
+	if err != nil {
+		return 0, err
+	}
+
+	approved := make(map[int]bool, len(approval.ProductIDs))
+	for _, productID := range approval.ProductIDs {
+		approved[productID] = true
+	}
+
+	query := `
+		SELECT id_producto, contado - esperado
+		FROM detalle_conteo
+		WHERE id_conteo = $1 AND contado IS NOT NULL AND contado <> esperado
+		ORDER BY id_producto;
+	`
+	rows, err := tx.QueryContext(ctx, query, countID)
+	if err != nil {
+		return 0, err
+	}
+
+	variances := make(map[int]int)
+	var products []int
+	for rows.Next() {
+		var productID, variance int
+		err := rows.Scan(&productID, &variance)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		if approval.ApproveAll || approved[productID] {
+			products = append(products, productID)
+			variances[productID] = variance
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementAdjustment, countReference(countID), "")
+	if err != nil {
+		return 0, err
+	}
+
+	// Surplus units come in at the average cost, so they do not move it, and missing ones leave the layers
+	for _, productID := range products {
+		if variances[productID] < 0 {
+			var stock int
+			query = `SELECT stock FROM existencia WHERE id_sucursal = $1 AND id_producto = $2 FOR UPDATE;`
+			err = tx.QueryRowContext(ctx, query, branchID, productID).Scan(&stock)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return 0, err
+			}
+
+			if stock+variances[productID] < 0 {
+				return 0, &repository.InsufficientStockError{
+					ProductID: productID,
+					Requested: -variances[productID],
+					Available: stock,
+				}
+			}
+		}
+
+		err = addBranchStock(ctx, tx, branchID, productID, variances[productID])
+		if err != nil {
+			return 0, err
+		}
+
+		if variances[productID] < 0 {
+			_, err = r.consumeCost(ctx, tx, productID, -variances[productID], 0, countReference(countID))
+		} else {
+			var unitCost float32
+			query = `SELECT COALESCE(NULLIF(costo_promedio, 0), precio_proveedor) FROM producto WHERE id_producto = $1;`
+			err = tx.QueryRowContext(ctx, query, productID).Scan(&unitCost)
+			if err != nil {
+				return 0, err
+			}
+			err = addCostLayer(ctx, tx, productID, variances[productID], unitCost, countReference(countID))
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		query = `UPDATE detalle_conteo SET ajuste = $1 WHERE id_conteo = $2 AND id_producto = $3;`
+		_, err = tx.ExecContext(ctx, query, variances[productID], countID, productID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query = `
+		UPDATE conteo_inventario
+		SET estado = $1, motivo = $2, fecha_cierre = CURRENT_TIMESTAMP
+		WHERE id_conteo = $3;
+	`
+	_, err = tx.ExecContext(ctx, query, models.CountPosted, approval.Reason, countID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(products), nil
+}
+
+// CancelInventoryCount cancels an open inventory count without touching stock
+func (r *Repository) CancelInventoryCount(countID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenCount(ctx, tx, countID)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE conteo_inventario SET estado = $1, fecha_cierre = CURRENT_TIMESTAMP WHERE id_conteo = $2;`
+	_, err = tx.ExecContext(ctx, query, models.CountCancelled, countID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockOpenCount locks an inventory count that is still open, returns the branch it counts
+func lockOpenCount(ctx context.Context, tx *sql.Tx, countID int) (int, error) {
+	var status string
+	var branchID int
+	query := `SELECT estado, id_sucursal FROM conteo_inventario WHERE id_conteo = $1 FOR UPDATE;`
+	err := tx.QueryRowContext(ctx, query, countID).Scan(&status, &branchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrCountNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if status != models.CountOpen {
+		return 0, repository.ErrCountNotOpen
+	}
+
+	return branchID, nil
+}
@@ -0,0 +1,345 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+const invoiceColumns = `
+	f.id_factura,
+	f.id_venta,
+	f.serie,
+	f.folio,
+	f.estado,
+	COALESCE(f.uuid, ''),
+	f.fecha,
+	f.fecha_timbrado,
+	f.tasa_iva,
+	COALESCE(f.metodo_pago, ''),
+	COALESCE(f.forma_pago, ''),
+	f.rfc_receptor,
+	f.nombre_receptor,
+	f.regimen_receptor,
+	f.cp_receptor,
+	f.uso_cfdi
+`
+
+// UpdateClientTaxData sets the tax data a client is invoiced with
+func (r *Repository) UpdateClientTaxData(clientID int, taxData models.TaxDataDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		UPDATE cliente
+		SET rfc = $1, razon_social = $2, regimen_fiscal = $3, codigo_postal_fiscal = $4, uso_cfdi = $5
+		WHERE id_cliente = $6;
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		taxData.RFC,
+		taxData.LegalName,
+		taxData.TaxRegime,
+		taxData.ZipCode,
+		taxData.CfdiUse,
+		clientID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// GetAllInvoices fetches all invoices from database without their lines, newest first
+func (r *Repository) GetAllInvoices() ([]models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `SELECT ` + invoiceColumns + ` FROM factura f ORDER BY f.id_factura DESC;`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// GetInvoice fetches an invoice with its lines and, once stamped, its XML
+func (r *Repository) GetInvoice(invoiceID int) (models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	inv, err := queryInvoice(ctx, tx, invoiceID)
+	if err != nil {
+		return inv, err
+	}
+
+	return inv, tx.Commit()
+}
+
+// ReserveInvoice assigns the next folio of series to the invoice of a sale, taking a copy of the tax data of its
+// client. A sale whose invoice is still waiting to be stamped gets that same invoice back, so a failed stamp
+// does not leave gaps in the folios.
+func (r *Repository) ReserveInvoice(saleID int, series string, taxRate float32) (models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	var clientID sql.NullInt64
+	query := `SELECT id_cliente FROM venta WHERE id_venta = $1 FOR UPDATE;`
+	err = tx.QueryRowContext(ctx, query, saleID).Scan(&clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Invoice{}, repository.ErrSaleNotFound
+	}
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	var invoiceID int
+	var status string
+	query = `SELECT id_factura, estado FROM factura WHERE id_venta = $1;`
+	err = tx.QueryRowContext(ctx, query, saleID).Scan(&invoiceID, &status)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.Invoice{}, err
+	}
+	if status == models.InvoiceStamped {
+		return models.Invoice{}, repository.ErrSaleInvoiced
+	}
+
+	if invoiceID == 0 {
+		// Returned products would have to be invoiced with a credit note, which is not supported
+		err = checkSaleWithoutReturns(ctx, tx, saleID)
+		if err != nil {
+			return models.Invoice{}, err
+		}
+
+		var hasTaxData bool
+		query = `SELECT rfc IS NOT NULL FROM cliente WHERE id_cliente = $1;`
+		err = tx.QueryRowContext(ctx, query, clientID.Int64).Scan(&hasTaxData)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return models.Invoice{}, err
+		}
+		if !hasTaxData {
+			return models.Invoice{}, repository.ErrClientWithoutTaxData
+		}
+
+		var folio int
+		query = `
+			INSERT INTO serie_factura (serie, ultimo_folio)
+			VALUES ($1, 1)
+			ON CONFLICT (serie) DO UPDATE SET ultimo_folio = serie_factura.ultimo_folio + 1
+			RETURNING ultimo_folio;
+		`
+		err = tx.QueryRowContext(ctx, query, series).Scan(&folio)
+		if err != nil {
+			return models.Invoice{}, err
+		}
+
+		query = `
+			INSERT INTO factura (
+				id_venta, serie, folio, estado, fecha, tasa_iva,
+				rfc_receptor, nombre_receptor, regimen_receptor, cp_receptor, uso_cfdi
+			)
+			SELECT $1, $2, $3, $4, CURRENT_TIMESTAMP, $5, rfc, razon_social, regimen_fiscal, codigo_postal_fiscal, uso_cfdi
+			FROM cliente
+			WHERE id_cliente = $6
+			RETURNING id_factura;
+		`
+		err = tx.QueryRowContext(ctx, query,
+			saleID, series, folio, models.InvoicePending, taxRate, clientID.Int64,
+		).Scan(&invoiceID)
+		if err != nil {
+			return models.Invoice{}, err
+		}
+	}
+
+	inv, err := queryInvoice(ctx, tx, invoiceID)
+	if err != nil {
+		return inv, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// StampInvoice stores the stamp and the XML of an invoice that was waiting to be stamped
+func (r *Repository) StampInvoice(inv models.Invoice) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		UPDATE factura
+		SET estado = $1, fecha = $2, uuid = $3, fecha_timbrado = $4, metodo_pago = $5, forma_pago = $6, xml = $7
+		WHERE id_factura = $8 AND estado = $9;
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		models.InvoiceStamped,
+		inv.Date,
+		inv.UUID,
+		inv.StampedAt,
+		inv.PaymentMethod,
+		inv.PaymentForm,
+		inv.XML,
+		inv.InvoiceID,
+		models.InvoicePending,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return repository.ErrInvoiceStamped
+	}
+
+	return nil
+}
+
+// queryInvoice fetches an invoice with its lines and the payment state of its sale
+func queryInvoice(ctx context.Context, tx *sql.Tx, invoiceID int) (models.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `, COALESCE(f.xml, ''), v.estado
+		FROM factura f
+		INNER JOIN venta v
+			ON v.id_venta = f.id_venta
+		WHERE f.id_factura = $1;
+	`
+
+	var document, saleStatus string
+	inv, err := scanInvoice(tx.QueryRowContext(ctx, query, invoiceID), &document, &saleStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return inv, repository.ErrInvoiceNotFound
+	}
+	if err != nil {
+		return inv, err
+	}
+	inv.XML = document
+	inv.SalePaid = saleStatus == models.SaleStatusFinalized
+
+	// The tender of the invoice is the payment method that covered most of the sale
+	query = `
+		SELECT metodo
+		FROM pago_venta
+		WHERE id_venta = $1
+		GROUP BY metodo
+		ORDER BY SUM(monto - cambio) DESC, metodo
+		LIMIT 1;
+	`
+	err = tx.QueryRowContext(ctx, query, inv.SaleID).Scan(&inv.SaleTender)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return inv, err
+	}
+
+	query = `
+		SELECT
+			p.id_producto,
+			p.clave_sat,
+			p.clasificacion || ' ' || p.marca,
+			d.cantidad,
+			d.precio_unitario,
+			d.descuento
+		FROM detalle_venta d
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		WHERE d.id_venta = $1
+		ORDER BY d.id_detalle;
+	`
+	rows, err := tx.QueryContext(ctx, query, inv.SaleID)
+	if err != nil {
+		return inv, err
+	}
+	defer rows.Close()
+
+	inv.Lines = []models.InvoiceLine{}
+	for rows.Next() {
+		l := models.InvoiceLine{}
+		err := rows.Scan(&l.ProductID, &l.SATKey, &l.Description, &l.Amount, &l.UnitPrice, &l.Discount)
+		if err != nil {
+			return inv, err
+		}
+		inv.Lines = append(inv.Lines, l)
+	}
+
+	return inv, rows.Err()
+}
+
+// checkSaleNotInvoiced fails with repository.ErrSaleInvoiced when a sale already has a folio assigned
+func checkSaleNotInvoiced(ctx context.Context, tx *sql.Tx, saleID int) error {
+	var isInvoiced bool
+	query := `SELECT EXISTS (SELECT 1 FROM factura WHERE id_venta = $1);`
+	err := tx.QueryRowContext(ctx, query, saleID).Scan(&isInvoiced)
+	if err != nil {
+		return err
+	}
+
+	if isInvoiced {
+		return repository.ErrSaleInvoiced
+	}
+
+	return nil
+}
+
+// scanInvoice reads an invoice selected with invoiceColumns followed by any extra columns
+func scanInvoice(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.Invoice, error) {
+	inv := models.Invoice{}
+	var stampedAt sql.NullTime
+	dest := []interface{}{
+		&inv.InvoiceID, &inv.SaleID, &inv.Series, &inv.Folio, &inv.Status, &inv.UUID, &inv.Date, &stampedAt,
+		&inv.TaxRate, &inv.PaymentMethod, &inv.PaymentForm,
+		&inv.Receiver.RFC, &inv.Receiver.LegalName, &inv.Receiver.TaxRegime, &inv.Receiver.ZipCode, &inv.Receiver.CfdiUse,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return inv, err
+	}
+	if stampedAt.Valid {
+		inv.StampedAt = &stampedAt.Time
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,123 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetKardex fetches the stock movements of a product between two dates, both optional, to is inclusive
+func (r *Repository) GetKardex(productID int, from, to *time.Time) (models.Kardex, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	kardex := models.Kardex{ProductID: productID, From: from, To: to, Movements: []models.StockMovement{}}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM producto WHERE id_producto = $1);`
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(&exists)
+	if err != nil {
+		return kardex, err
+	}
+	if !exists {
+		return kardex, repository.ErrProductNotFound
+	}
+
+	var since, until sql.NullTime
+	if from != nil {
+		since = sql.NullTime{Time: *from, Valid: true}
+	}
+	if to != nil {
+		until = sql.NullTime{Time: to.AddDate(0, 0, 1), Valid: true}
+	}
+
+	// The opening stock is the balance left by the last movement before the period
+	query = `
+		SELECT COALESCE((
+			SELECT saldo
+			FROM movimiento_inventario
+			WHERE id_producto = $1 AND fecha < $2
+			ORDER BY id_movimiento DESC
+			LIMIT 1
+		), 0);
+	`
+	if since.Valid {
+		err = r.db.QueryRowContext(ctx, query, productID, since).Scan(&kardex.Opening)
+		if err != nil {
+			return kardex, err
+		}
+	}
+	kardex.Closing = kardex.Opening
+
+	query = `
+		SELECT id_movimiento, fecha, tipo, cantidad, saldo, COALESCE(referencia, ''), usuario, COALESCE(id_sucursal, 0)
+		FROM movimiento_inventario
+		WHERE id_producto = $1
+			AND ($2::timestamp IS NULL OR fecha >= $2)
+			AND ($3::timestamp IS NULL OR fecha < $3)
+		ORDER BY id_movimiento;
+	`
+	rows, err := r.db.QueryContext(ctx, query, productID, since, until)
+	if err != nil {
+		return kardex, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := models.StockMovement{}
+		err := rows.Scan(&m.MovementID, &m.Date, &m.Kind, &m.Quantity, &m.Balance, &m.Reference, &m.User, &m.BranchID)
+		if err != nil {
+			return kardex, err
+		}
+		kardex.Movements = append(kardex.Movements, m)
+		kardex.Closing = m.Balance
+	}
+
+	return kardex, rows.Err()
+}
+
+// recordStockMovement describes the stock changes the transaction makes from now on.
+//
+// The ledger is written by a trigger over producto.stock, so changes made by other triggers are recorded too, and
+// the stock of a branch reaches producto.stock through a trigger over existencia that also tells the branch;
+// it reads the type, reference and user from these transaction settings. An empty user falls back to the
+// database user.
+func recordStockMovement(ctx context.Context, tx *sql.Tx, kind, reference, user string) error {
+	query := `
+		SELECT
+			set_config('inventario.tipo', $1, true),
+			set_config('inventario.referencia', $2, true),
+			set_config('inventario.usuario', $3, true);
+	`
+	_, err := tx.ExecContext(ctx, query, kind, reference, user)
+	return err
+}
+
+// saleReference reference of the stock movements caused by a sale
+func saleReference(saleID int) string {
+	return fmt.Sprintf("venta %d", saleID)
+}
+
+// returnReference reference of the stock movements caused by a return
+func returnReference(returnID int) string {
+	return fmt.Sprintf("devolución %d", returnID)
+}
+
+// purchaseOrderReference reference of the stock movements caused by receiving a purchase order
+func purchaseOrderReference(orderID int) string {
+	return fmt.Sprintf("orden de compra %d", orderID)
+}
+
+// countReference reference of the stock adjustments posted by an inventory count
+func countReference(countID int) string {
+	return fmt.Sprintf("conteo de inventario %d", countID)
+}
+
+// transferReference reference of the stock movements caused by a transfer between branches
+func transferReference(transferID int) string {
+	return fmt.Sprintf("traspaso %d", transferID)
+}
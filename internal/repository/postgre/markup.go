@@ -0,0 +1,251 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+const markupRuleColumns = `
+	id_regla,
+	COALESCE(id_categoria, 0),
+	COALESCE(marca, ''),
+	COALESCE(id_proveedor, 0),
+	margen,
+	margen_minimo,
+	bloquear
+`
+
+// GetAllMarkupRules fetches the markup rules, the ones by category first, then by brand and then by provider
+func (r *Repository) GetAllMarkupRules() ([]models.MarkupRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	rules := []models.MarkupRule{}
+	query := `
+		SELECT ` + markupRuleColumns + `
+		FROM regla_margen
+		ORDER BY id_categoria NULLS LAST, LOWER(marca) NULLS LAST, id_proveedor;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := models.MarkupRule{}
+		err := rows.Scan(&m.RuleID, &m.CategoryID, &m.Brand, &m.ProviderID, &m.Markup, &m.MinMargin, &m.Block)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// InsertMarkupRule inserts a markup rule for a category, brand or provider without one, returns its id
+func (r *Repository) InsertMarkupRule(rule models.MarkupRuleDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rule.Brand = strings.TrimSpace(rule.Brand)
+	err = checkMarkupScope(ctx, tx, rule, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var ruleID int
+	query := `
+		INSERT INTO regla_margen (id_categoria, marca, id_proveedor, margen, margen_minimo, bloquear)
+		VALUES (NULLIF($1, 0), NULLIF(marca_canonica($2), ''), NULLIF($3, 0), $4, $5, $6) RETURNING id_regla;
+	`
+	err = tx.QueryRowContext(ctx, query,
+		rule.CategoryID,
+		rule.Brand,
+		rule.ProviderID,
+		rule.Markup,
+		rule.MinMargin,
+		rule.Block,
+	).Scan(&ruleID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return ruleID, nil
+}
+
+// UpdateMarkupRule rewrites a markup rule, its scope can change to a category, brand or provider without one
+func (r *Repository) UpdateMarkupRule(ruleID int, rule models.MarkupRuleDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rule.Brand = strings.TrimSpace(rule.Brand)
+	err = checkMarkupScope(ctx, tx, rule, ruleID)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE regla_margen
+		SET
+			id_categoria = NULLIF($1, 0),
+			marca = NULLIF(marca_canonica($2), ''),
+			id_proveedor = NULLIF($3, 0),
+			margen = $4,
+			margen_minimo = $5,
+			bloquear = $6
+		WHERE id_regla = $7;
+	`
+	result, err := tx.ExecContext(ctx, query,
+		rule.CategoryID,
+		rule.Brand,
+		rule.ProviderID,
+		rule.Markup,
+		rule.MinMargin,
+		rule.Block,
+		ruleID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// DeleteMarkupRule deletes a markup rule, the prices it suggested are kept
+func (r *Repository) DeleteMarkupRule(ruleID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM regla_margen WHERE id_regla = $1;`, ruleID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetPriceSuggestion finds the markup rule of a product of a category, brand and preferred provider, and prices a
+// cost with it. A rule by category wins over one by brand, which wins over one by provider; the rules of the
+// categories above the one of the product apply too, the closest one first
+func (r *Repository) GetPriceSuggestion(categoryID int, brand string, providerID int, cost float32) (models.PriceSuggestion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	suggestion := models.PriceSuggestion{Cost: cost}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return suggestion, err
+	}
+	defer tx.Rollback()
+
+	rule, err := findMarkupRule(ctx, tx, categoryID, brand, providerID)
+	if err != nil || rule == nil {
+		return suggestion, err
+	}
+
+	suggestion.Rule = rule
+	suggestion.Suggested = pricing.SuggestPrice(cost, *rule)
+	suggestion.MinimumPrice = pricing.MinimumPrice(cost, *rule)
+
+	return suggestion, tx.Commit()
+}
+
+// findMarkupRule finds the markup rule of a product of a category, brand and preferred provider by the precedence
+// of GetPriceSuggestion, nil when no rule applies
+func findMarkupRule(ctx context.Context, tx *sql.Tx, categoryID int, brand string, providerID int) (*models.MarkupRule, error) {
+	query := `
+		SELECT ` + markupRuleColumns + `
+		FROM regla_margen
+		LEFT JOIN categorias_ascendentes($1) a
+			USING (id_categoria)
+		WHERE a.nivel IS NOT NULL OR LOWER(marca) = LOWER(marca_canonica($2)) OR id_proveedor = $3
+		ORDER BY CASE WHEN a.nivel IS NOT NULL THEN 0 WHEN marca IS NOT NULL THEN 1 ELSE 2 END, a.nivel
+		LIMIT 1;
+	`
+
+	m := models.MarkupRule{}
+	err := tx.QueryRowContext(ctx, query, categoryID, strings.TrimSpace(brand), providerID).Scan(
+		&m.RuleID, &m.CategoryID, &m.Brand, &m.ProviderID, &m.Markup, &m.MinMargin, &m.Block,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// checkMarkupScope fails when the category or provider of a rule does not exist, or when another rule than the
+// given one already covers its category, brand or provider
+func checkMarkupScope(ctx context.Context, tx *sql.Tx, rule models.MarkupRuleDTO, ruleID int) error {
+	if rule.CategoryID != 0 {
+		err := checkCategoryExists(ctx, tx, rule.CategoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rule.ProviderID != 0 {
+		err := checkProviderExists(ctx, tx, rule.ProviderID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var taken bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM regla_margen
+			WHERE id_regla <> $1 AND (id_categoria = $2 OR LOWER(marca) = LOWER(marca_canonica($3)) OR id_proveedor = $4)
+		);
+	`
+	err := tx.QueryRowContext(ctx, query, ruleID, rule.CategoryID, rule.Brand, rule.ProviderID).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return repository.ErrMarkupRuleExists
+	}
+
+	return nil
+}
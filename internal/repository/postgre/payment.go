@@ -0,0 +1,176 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// AddSalePayments registers payments over a pending sale at an open register session, the sale is finalized
+// once they cover its total net of the refunds that settled part of it
+func (r *Repository) AddSalePayments(saleID int, payment models.SalePaymentDTO) (models.SaleReceipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	receipt := models.SaleReceipt{SaleID: saleID}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return receipt, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenSession(ctx, tx, payment.SessionID)
+	if err != nil {
+		return receipt, err
+	}
+
+	query := `
+		SELECT
+			v.estado,
+			v.subtotal,
+			v.iva,
+			v.total - ` + settledRefundsQuery + `,
+			COALESCE((SELECT SUM(pv.monto - pv.cambio) FROM pago_venta pv WHERE pv.id_venta = v.id_venta), 0)
+		FROM venta v
+		WHERE v.id_venta = $1
+		FOR UPDATE;
+	`
+	err = tx.QueryRowContext(ctx, query, saleID).Scan(
+		&receipt.Status, &receipt.SubTotal, &receipt.Tax, &receipt.Total, &receipt.Paid,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return receipt, repository.ErrSaleNotFound
+	}
+	if err != nil {
+		return receipt, err
+	}
+
+	if receipt.Status == models.SaleStatusFinalized {
+		return receipt, repository.ErrSaleFinalized
+	}
+
+	receipt, err = applyPayments(ctx, tx, payment.SessionID, receipt, payment.Payments)
+	if err != nil {
+		return receipt, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return receipt, err
+	}
+
+	return receipt, nil
+}
+
+// applyPayments inserts payments received at a register session over a sale and finalizes it when they cover
+// its total.
+//
+// Only cash can go over the pending amount, the difference is recorded as change on the cash payments from the
+// last one back, none of them giving more change than its amount.
+func applyPayments(ctx context.Context, tx *sql.Tx, sessionID int, receipt models.SaleReceipt, payments []models.PaymentDTO) (models.SaleReceipt, error) {
+	pending := pricing.RoundCents(receipt.Total - receipt.Paid)
+
+	var incoming, cash float32
+	for _, payment := range payments {
+		incoming += payment.Amount
+		if payment.Method == models.PaymentCash {
+			cash += payment.Amount
+		}
+	}
+
+	change := pricing.RoundCents(incoming - pending)
+	if change < 0 {
+		change = 0
+	}
+	if change > pricing.RoundCents(cash) {
+		return receipt, repository.ErrPaymentExceedsTotal
+	}
+
+	changes := make([]float32, len(payments))
+	remaining := change
+	for i := len(payments) - 1; i >= 0 && remaining > 0; i-- {
+		if payments[i].Method != models.PaymentCash {
+			continue
+		}
+
+		changes[i] = remaining
+		if changes[i] > payments[i].Amount {
+			changes[i] = payments[i].Amount
+		}
+		remaining = pricing.RoundCents(remaining - changes[i])
+	}
+
+	query := `
+		INSERT INTO pago_venta (id_venta, id_sesion, fecha, metodo, monto, referencia, cambio)
+		VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6);
+	`
+	for i, payment := range payments {
+		_, err := tx.ExecContext(ctx, query,
+			receipt.SaleID,
+			sessionID,
+			payment.Method,
+			payment.Amount,
+			payment.Reference,
+			changes[i],
+		)
+		if err != nil {
+			return receipt, err
+		}
+	}
+
+	receipt.Paid = pricing.RoundCents(receipt.Paid + incoming - change)
+	receipt.Change = change
+	receipt.Status = models.SaleStatusPending
+	if receipt.Paid >= receipt.Total {
+		receipt.Status = models.SaleStatusFinalized
+	}
+
+	query = `UPDATE venta SET estado = $1 WHERE id_venta = $2;`
+	_, err := tx.ExecContext(ctx, query, receipt.Status, receipt.SaleID)
+	if err != nil {
+		return receipt, err
+	}
+
+	return receipt, nil
+}
+
+// attachPayments fetches the payments of every sale and sets them on the sales they belong to
+func (r *Repository) attachPayments(ctx context.Context, sales []models.Sale) error {
+	index := make(map[int]int, len(sales))
+	for i := range sales {
+		sales[i].Payments = []models.Payment{}
+		index[sales[i].SaleID] = i
+	}
+
+	query := `
+		SELECT id_venta, id_pago, fecha, metodo, monto, referencia, cambio
+		FROM pago_venta
+		ORDER BY id_venta, id_pago;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var saleID int
+		p := models.Payment{}
+		err := rows.Scan(&saleID, &p.PaymentID, &p.Date, &p.Method, &p.Amount, &p.Reference, &p.Change)
+		if err != nil {
+			return err
+		}
+
+		if i, ok := index[saleID]; ok {
+			sales[i].Payments = append(sales[i].Payments, p)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,231 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// UpdatePrices moves the public or provider price of every product of a brand, a category and a preferred provider
+// at once, the filters left empty match every product and a category includes the categories under it. Products
+// whose price does not change are left out, and no product can be left at a price of zero or below.
+//
+// A dry run returns the same lines without writing them. A provider price also becomes the cost with the
+// preferred provider, as when it is edited on the product.
+//
+// Every line is checked against its markup rule as when a product is saved: public prices left under the minimum
+// margin get a warning, and when the rule blocks them the update fails with repository.ErrBelowMinimumMargin
+// along with the lines, so a dry run only flags them.
+func (r *Repository) UpdatePrices(update models.PriceUpdateDTO) (models.PriceUpdate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	result := models.PriceUpdate{DryRun: update.DryRun, Target: update.Target, Lines: []models.PriceUpdateLine{}}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			p.id_producto,
+			p.clasificacion,
+			p.marca,
+			p.precio_publico,
+			p.precio_proveedor,
+			p.id_categoria,
+			COALESCE((SELECT id_proveedor FROM producto_proveedor WHERE id_producto = p.id_producto AND preferido), 0)
+		FROM producto p
+		WHERE ($1 = '' OR LOWER(p.marca) = LOWER(marca_canonica($1)))
+			AND ($2 = 0 OR p.id_categoria IN (SELECT id_categoria FROM subcategorias($2)))
+			AND ($3 = 0 OR EXISTS (
+				SELECT 1 FROM producto_proveedor pp WHERE pp.id_producto = p.id_producto AND pp.id_proveedor = $3 AND pp.preferido
+			))
+		ORDER BY p.id_producto
+		FOR UPDATE OF p;
+	`
+	rows, err := tx.QueryContext(ctx, query, strings.TrimSpace(update.Brand), update.CategoryID, update.ProviderID)
+	if err != nil {
+		return result, err
+	}
+
+	for rows.Next() {
+		l := models.PriceUpdateLine{}
+		err := rows.Scan(
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+			&l.Product.PublicPrice, &l.Product.ProviderPrice,
+			&l.Product.Category.CategoryID, &l.Product.Provider.ProviderID,
+		)
+		if err != nil {
+			rows.Close()
+			return result, err
+		}
+
+		l.Previous = l.Product.PublicPrice
+		if update.Target == models.PriceProvider {
+			l.Previous = l.Product.ProviderPrice
+		}
+
+		l.Price = pricing.Reprice(l.Previous, update.Mode, update.Value, update.Rounding)
+		if l.Price <= 0 {
+			rows.Close()
+			return result, repository.ErrNegativePrice
+		}
+
+		if l.Price != l.Previous {
+			result.Lines = append(result.Lines, l)
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	result.Updated = len(result.Lines)
+
+	for i := range result.Lines {
+		err = checkLineMargin(ctx, tx, update.Target, &result.Lines[i])
+		if err != nil {
+			return result, err
+		}
+
+		if result.Lines[i].Blocked {
+			result.Blocked++
+		}
+	}
+
+	if update.DryRun {
+		return result, nil
+	}
+
+	if result.Blocked > 0 {
+		return result, repository.ErrBelowMinimumMargin
+	}
+
+	err = recordPriceChange(ctx, tx, models.PriceChangeBulk, strings.TrimSpace(update.Reason), "")
+	if err != nil {
+		return result, err
+	}
+
+	for _, line := range result.Lines {
+		query = `UPDATE producto SET precio_publico = $1 WHERE id_producto = $2;`
+		if update.Target == models.PriceProvider {
+			query = `UPDATE producto SET precio_proveedor = $1 WHERE id_producto = $2;`
+		}
+		_, err = tx.ExecContext(ctx, query, line.Price, line.Product.ProductID)
+		if err != nil {
+			return result, err
+		}
+
+		if update.Target != models.PriceProvider {
+			continue
+		}
+
+		query = `UPDATE producto_proveedor SET costo = $1 WHERE id_producto = $2 AND preferido;`
+		_, err = tx.ExecContext(ctx, query, line.Price, line.Product.ProductID)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// checkLineMargin compares the public price a line leaves against the minimum price of the markup rule of its
+// product, over the provider price it leaves, and flags the line when it falls under it
+func checkLineMargin(ctx context.Context, tx *sql.Tx, target string, line *models.PriceUpdateLine) error {
+	public, cost := line.Price, line.Product.ProviderPrice
+	if target == models.PriceProvider {
+		public, cost = line.Product.PublicPrice, line.Price
+	}
+
+	product := line.Product
+	rule, err := findMarkupRule(ctx, tx, product.Category.CategoryID, product.Brand, product.Provider.ProviderID)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	minimum := pricing.MinimumPrice(cost, *rule)
+	if public >= minimum {
+		return nil
+	}
+
+	line.MinimumPrice = minimum
+	line.Rule = rule
+	line.Blocked = rule.Block
+	return nil
+}
+
+// GetProductPriceHistory fetches every change of the public and provider prices of a product, oldest first
+func (r *Repository) GetProductPriceHistory(productID int) ([]models.PriceChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.PriceChange{}
+	query := `
+		SELECT id_historial, fecha, tipo, anterior, precio, origen, COALESCE(referencia, ''), usuario
+		FROM historial_precio
+		WHERE id_producto = $1
+		ORDER BY id_historial;
+	`
+
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var previous sql.NullFloat64
+		c := models.PriceChange{}
+		err := rows.Scan(&c.ChangeID, &c.Date, &c.Kind, &previous, &c.Price, &c.Origin, &c.Reference, &c.User)
+		if err != nil {
+			return nil, err
+		}
+
+		if previous.Valid {
+			price := float32(previous.Float64)
+			c.Previous = &price
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, tx.Commit()
+}
+
+// recordPriceChange describes the price changes the transaction makes from now on.
+//
+// The price history is written by a trigger over the prices of producto, it reads the origin, reference and user
+// from these transaction settings. An empty user falls back to the database user.
+func recordPriceChange(ctx context.Context, tx *sql.Tx, origin, reference, user string) error {
+	query := `
+		SELECT
+			set_config('precio.origen', $1, true),
+			set_config('precio.referencia', $2, true),
+			set_config('precio.usuario', $3, true);
+	`
+	_, err := tx.ExecContext(ctx, query, origin, reference, user)
+	return err
+}
@@ -0,0 +1,235 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+const promotionColumns = `
+	id_promocion,
+	nombre,
+	tipo,
+	porcentaje,
+	compra,
+	regalo,
+	COALESCE(id_producto, 0),
+	COALESCE(marca, ''),
+	COALESCE(id_categoria, 0),
+	COALESCE(id_cliente, 0),
+	fecha_inicio,
+	fecha_fin,
+	activa
+`
+
+// GetAllPromotions fetches all promotions from database
+func (r *Repository) GetAllPromotions() ([]models.Promotion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `SELECT ` + promotionColumns + ` FROM promocion ORDER BY id_promocion;`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPromotions(rows)
+}
+
+// InsertPromotion inserts a promotion into database
+func (r *Repository) InsertPromotion(promotion models.PromotionDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		INSERT INTO promocion
+			(nombre, tipo, porcentaje, compra, regalo, id_producto, marca, id_categoria, id_cliente, fecha_inicio, fecha_fin, activa)
+		VALUES
+			($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF(marca_canonica($7), ''), NULLIF($8, 0), NULLIF($9, 0), $10, $11, $12);
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		promotion.Name,
+		promotion.Kind,
+		promotion.Percent,
+		promotion.Buy,
+		promotion.Free,
+		promotion.ProductID,
+		promotion.Brand,
+		promotion.CategoryID,
+		promotion.ClientID,
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.Active,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePromotion updates a promotion in database
+func (r *Repository) UpdatePromotion(promotionID int, promotion models.PromotionDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		UPDATE promocion
+		SET
+			nombre = $1,
+			tipo = $2,
+			porcentaje = $3,
+			compra = $4,
+			regalo = $5,
+			id_producto = NULLIF($6, 0),
+			marca = NULLIF(marca_canonica($7), ''),
+			id_categoria = NULLIF($8, 0),
+			id_cliente = NULLIF($9, 0),
+			fecha_inicio = $10,
+			fecha_fin = $11,
+			activa = $12
+		WHERE id_promocion = $13;
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		promotion.Name,
+		promotion.Kind,
+		promotion.Percent,
+		promotion.Buy,
+		promotion.Free,
+		promotion.ProductID,
+		promotion.Brand,
+		promotion.CategoryID,
+		promotion.ClientID,
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.Active,
+		promotionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// DeletePromotion deactivates a promotion, it is kept because sale lines keep track of the promotion they got
+func (r *Repository) DeletePromotion(promotionID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `UPDATE promocion SET activa = FALSE WHERE id_promocion = $1;`
+
+	result, err := r.db.ExecContext(ctx, query, promotionID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// activePromotions fetches the promotions that are active and valid today inside a transaction, category
+// promotions carry the categories nested under their category
+func activePromotions(ctx context.Context, tx *sql.Tx) ([]models.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promocion
+		WHERE activa AND CURRENT_DATE BETWEEN fecha_inicio AND fecha_fin;
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := scanPromotions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachSubcategories(ctx, tx, promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// attachSubcategories sets on every category promotion the categories nested under its category
+func attachSubcategories(ctx context.Context, tx *sql.Tx, promotions []models.Promotion) error {
+	index := make(map[int]int, len(promotions))
+	for i := range promotions {
+		if promotions[i].CategoryID != 0 {
+			index[promotions[i].PromotionID] = i
+		}
+	}
+
+	if len(index) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT p.id_promocion, s.id_categoria
+		FROM promocion p
+		CROSS JOIN LATERAL subcategorias(p.id_categoria) s
+		WHERE p.activa AND CURRENT_DATE BETWEEN p.fecha_inicio AND p.fecha_fin AND s.id_categoria <> p.id_categoria;
+	`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var promotionID, categoryID int
+		if err := rows.Scan(&promotionID, &categoryID); err != nil {
+			return err
+		}
+
+		if i, ok := index[promotionID]; ok {
+			promotions[i].Subcategories = append(promotions[i].Subcategories, categoryID)
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanPromotions reads every promotion of rows selected with promotionColumns and closes them
+func scanPromotions(rows *sql.Rows) ([]models.Promotion, error) {
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		p := models.Promotion{}
+		err := rows.Scan(
+			&p.PromotionID, &p.Name, &p.Kind, &p.Percent, &p.Buy, &p.Free,
+			&p.ProductID, &p.Brand, &p.CategoryID, &p.ClientID,
+			&p.StartsAt, &p.EndsAt, &p.Active,
+		)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
@@ -0,0 +1,248 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetProductProviders fetches the providers of a product, cheapest first and, between the same cost, fastest first
+func (r *Repository) GetProductProviders(productID int) ([]models.ProductProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := queryProductProviders(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return links[productID], tx.Commit()
+}
+
+// SetProductProvider links a provider to a product or updates the terms of an existing link. The first provider of
+// a product is always its preferred one
+func (r *Repository) SetProductProvider(productID int, link models.ProductProviderDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+
+	err = checkProviderExists(ctx, tx, link.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	// The product row serializes concurrent changes of its preferred provider
+	var hasPreferred bool
+	query := `
+		SELECT EXISTS (SELECT 1 FROM producto_proveedor WHERE id_producto = p.id_producto AND preferido)
+		FROM producto p
+		WHERE p.id_producto = $1
+		FOR UPDATE;
+	`
+	err = tx.QueryRowContext(ctx, query, productID).Scan(&hasPreferred)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO producto_proveedor (id_producto, id_proveedor, fecha_entrega, cantidad_surtir, sku_proveedor, costo, dias_entrega)
+		VALUES ($1, $2, NULL, NULL, $3, $4, $5)
+		ON CONFLICT (id_producto, id_proveedor) DO UPDATE
+		SET sku_proveedor = EXCLUDED.sku_proveedor, costo = EXCLUDED.costo, dias_entrega = EXCLUDED.dias_entrega;
+	`
+	_, err = tx.ExecContext(ctx, query, productID, link.ProviderID, link.SKU, link.Cost, link.LeadTime)
+	if err != nil {
+		return err
+	}
+
+	if link.Preferred || !hasPreferred {
+		err = setPreferredProvider(ctx, tx, productID, link.ProviderID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteProductProvider unlinks a provider from a product, the preferred provider can not be unlinked
+func (r *Repository) DeleteProductProvider(productID, providerID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var preferred bool
+	query := `SELECT preferido FROM producto_proveedor WHERE id_producto = $1 AND id_proveedor = $2 FOR UPDATE;`
+	err = tx.QueryRowContext(ctx, query, productID, providerID).Scan(&preferred)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if preferred {
+		return 0, repository.ErrPreferredProvider
+	}
+
+	query = `DELETE FROM producto_proveedor WHERE id_producto = $1 AND id_proveedor = $2;`
+	result, err := tx.ExecContext(ctx, query, productID, providerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
+
+// linkProvider links a provider to a product with the given cost when it is not linked yet, an existing link is
+// left as it is.
+//
+// The link is written with the same row the product insert always wrote, without delivery date nor quantity, so
+// the legacy delivery triggers see what they always did.
+func linkProvider(ctx context.Context, tx *sql.Tx, productID, providerID int, cost float32) error {
+	var linked bool
+	query := `SELECT EXISTS (SELECT 1 FROM producto_proveedor WHERE id_producto = $1 AND id_proveedor = $2);`
+	err := tx.QueryRowContext(ctx, query, productID, providerID).Scan(&linked)
+	if err != nil || linked {
+		return err
+	}
+
+	query = `
+		INSERT INTO producto_proveedor (id_producto, id_proveedor, fecha_entrega, cantidad_surtir, costo)
+		VALUES ($1, $2, NULL, NULL, $3);
+	`
+	_, err = tx.ExecContext(ctx, query, productID, providerID, cost)
+	return err
+}
+
+// setPreferredProvider makes an already linked provider the preferred one of a product, only the preferred flag of
+// the links is written
+func setPreferredProvider(ctx context.Context, tx *sql.Tx, productID, providerID int) error {
+	// The previous preferred provider is unset first, a product can not have two at any moment
+	query := `UPDATE producto_proveedor SET preferido = FALSE WHERE id_producto = $1 AND id_proveedor <> $2 AND preferido;`
+	_, err := tx.ExecContext(ctx, query, productID, providerID)
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE producto_proveedor SET preferido = TRUE WHERE id_producto = $1 AND id_proveedor = $2;`
+	_, err = tx.ExecContext(ctx, query, productID, providerID)
+	return err
+}
+
+// queryProductProviders fetches the providers of a product, or of every product when the id is 0, grouped by product
+func queryProductProviders(ctx context.Context, tx *sql.Tx, productID int) (map[int][]models.ProductProvider, error) {
+	query := `
+		SELECT
+			pp.id_producto,
+			pp.sku_proveedor,
+			pp.costo,
+			pp.dias_entrega,
+			pp.preferido,
+			pr.codigo,
+			pr.nombre_proveedor,
+			pr.correo,
+			pr.telefono_proveedor
+		FROM producto_proveedor pp
+		INNER JOIN proveedor pr
+			ON pr.codigo = pp.id_proveedor
+		WHERE $1 = 0 OR pp.id_producto = $1
+		ORDER BY pp.id_producto, pp.costo, pp.dias_entrega, pr.codigo;
+	`
+
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(map[int][]models.ProductProvider)
+	if productID != 0 {
+		links[productID] = []models.ProductProvider{}
+	}
+	for rows.Next() {
+		var id int
+		l := models.ProductProvider{}
+		err := rows.Scan(
+			&id, &l.SKU, &l.Cost, &l.LeadTime, &l.Preferred,
+			&l.Provider.ProviderID, &l.Provider.Name, &l.Provider.Email, &l.Provider.Phone,
+		)
+		if err != nil {
+			return nil, err
+		}
+		links[id] = append(links[id], l)
+	}
+
+	return links, rows.Err()
+}
+
+// checkProviderExists fails with repository.ErrProviderNotFound when a provider does not exist
+func checkProviderExists(ctx context.Context, tx *sql.Tx, providerID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM proveedor WHERE codigo = $1);`
+	err := tx.QueryRowContext(ctx, query, providerID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return repository.ErrProviderNotFound
+	}
+
+	return nil
+}
+
+// checkProductExists fails with repository.ErrProductNotFound when a product does not exist
+func checkProductExists(ctx context.Context, tx *sql.Tx, productID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM producto WHERE id_producto = $1);`
+	err := tx.QueryRowContext(ctx, query, productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return repository.ErrProductNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,544 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllPurchaseOrders fetches all purchase orders from database with their lines, newest first
+func (r *Repository) GetAllPurchaseOrders() ([]models.PurchaseOrder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return r.queryPurchaseOrders(ctx, 0)
+}
+
+// GetPurchaseOrder fetches a single purchase order with its lines
+func (r *Repository) GetPurchaseOrder(orderID int) (models.PurchaseOrder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	orders, err := r.queryPurchaseOrders(ctx, orderID)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if len(orders) == 0 {
+		return models.PurchaseOrder{}, repository.ErrPurchaseOrderNotFound
+	}
+
+	return orders[0], nil
+}
+
+// InsertPurchaseOrder inserts a draft purchase order, returns its id
+func (r *Repository) InsertPurchaseOrder(order models.PurchaseOrderDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	orderID, err := insertPurchaseOrder(ctx, tx, order, models.PurchaseDraft)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return orderID, nil
+}
+
+// UpdatePurchaseOrder rewrites the provider, branch, expected date and lines of a draft purchase order
+func (r *Repository) UpdatePurchaseOrder(orderID int, order models.PurchaseOrderDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if status != models.PurchaseDraft {
+		return repository.ErrPurchaseOrderStatus
+	}
+
+	err = checkProviderExists(ctx, tx, order.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	branchID := branchOrMain(order.BranchID)
+	err = checkBranchExists(ctx, tx, branchID)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE orden_compra SET id_proveedor = $1, id_sucursal = $2, fecha_esperada = $3 WHERE id_orden = $4;`
+	_, err = tx.ExecContext(ctx, query, order.ProviderID, branchID, order.ExpectedDate, orderID)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM detalle_orden_compra WHERE id_orden = $1;`
+	_, err = tx.ExecContext(ctx, query, orderID)
+	if err != nil {
+		return err
+	}
+
+	err = insertPurchaseLines(ctx, tx, orderID, order.Lines)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to its provider, from then on it can be received
+func (r *Repository) SendPurchaseOrder(orderID int) error {
+	return r.changePurchaseOrderStatus(orderID, models.PurchaseSent, models.PurchaseDraft)
+}
+
+// CancelPurchaseOrder cancels a purchase order that was not fully received, the units already received are kept
+func (r *Repository) CancelPurchaseOrder(orderID int) error {
+	return r.changePurchaseOrderStatus(orderID, models.PurchaseCancelled,
+		models.PurchaseDraft, models.PurchaseSent, models.PurchasePartial)
+}
+
+// ReceivePurchaseOrder registers a shipment received against a sent purchase order and updates stock in the same
+// transaction, returns the id of the receipt
+func (r *Repository) ReceivePurchaseOrder(orderID int, receipt models.GoodsReceiptDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(ctx, tx, orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	if status != models.PurchaseSent && status != models.PurchasePartial {
+		return 0, repository.ErrPurchaseOrderStatus
+	}
+
+	receiptID, err := insertGoodsReceipt(ctx, tx, orderID, receipt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = refreshPurchaseOrderStatus(ctx, tx, orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return receiptID, nil
+}
+
+// GetPurchaseOrderReceipts fetches the receipts of a purchase order with their lines, oldest first
+func (r *Repository) GetPurchaseOrderReceipts(orderID int) ([]models.GoodsReceipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM orden_compra WHERE id_orden = $1);`
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, repository.ErrPurchaseOrderNotFound
+	}
+
+	receipts := []models.GoodsReceipt{}
+	query = `
+		SELECT
+			rc.id_recepcion,
+			rc.id_orden,
+			rc.fecha,
+			rc.notas,
+			d.id_detalle_orden,
+			d.cantidad,
+			d.danado,
+			d.rechazado,
+			d.costo_unitario,
+			p.id_producto,
+			p.clasificacion,
+			p.marca
+		FROM recepcion rc
+		INNER JOIN detalle_recepcion d
+			ON d.id_recepcion = rc.id_recepcion
+		INNER JOIN detalle_orden_compra o
+			ON o.id_detalle_orden = d.id_detalle_orden
+		INNER JOIN producto p
+			ON p.id_producto = o.id_producto
+		WHERE rc.id_orden = $1
+		ORDER BY rc.id_recepcion, d.id_detalle_recepcion;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rc := models.GoodsReceipt{}
+		l := models.GoodsReceiptLine{}
+		err := rows.Scan(
+			&rc.ReceiptID, &rc.OrderID, &rc.Date, &rc.Notes,
+			&l.LineID, &l.Received, &l.Damaged, &l.Rejected, &l.UnitCost,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(receipts) == 0 || receipts[len(receipts)-1].ReceiptID != rc.ReceiptID {
+			rc.Lines = []models.GoodsReceiptLine{}
+			receipts = append(receipts, rc)
+		}
+		last := &receipts[len(receipts)-1]
+		last.Lines = append(last.Lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// changePurchaseOrderStatus moves a purchase order to status when its current status is one of from
+func (r *Repository) changePurchaseOrderStatus(orderID int, status string, from ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockPurchaseOrder(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, s := range from {
+		if current == s {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return repository.ErrPurchaseOrderStatus
+	}
+
+	query := `UPDATE orden_compra SET estado = $1 WHERE id_orden = $2;`
+	_, err = tx.ExecContext(ctx, query, status, orderID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryPurchaseOrders fetches the purchase order with the given id, or every purchase order when the id is 0
+func (r *Repository) queryPurchaseOrders(ctx context.Context, orderID int) ([]models.PurchaseOrder, error) {
+	orders := []models.PurchaseOrder{}
+	query := `
+		SELECT
+			o.id_orden,
+			o.estado,
+			o.fecha,
+			o.fecha_esperada,
+			pr.codigo,
+			pr.nombre_proveedor,
+			pr.correo,
+			s.id_sucursal,
+			s.nombre,
+			d.id_detalle_orden,
+			d.cantidad,
+			d.cantidad_recibida,
+			d.costo_unitario,
+			p.id_producto,
+			p.clasificacion,
+			p.marca
+		FROM orden_compra o
+		INNER JOIN proveedor pr
+			ON pr.codigo = o.id_proveedor
+		INNER JOIN sucursal s
+			ON s.id_sucursal = o.id_sucursal
+		INNER JOIN detalle_orden_compra d
+			ON d.id_orden = o.id_orden
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		WHERE $1 = 0 OR o.id_orden = $1
+		ORDER BY o.id_orden DESC, d.id_detalle_orden;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o := models.PurchaseOrder{}
+		l := models.PurchaseOrderLine{}
+		err := rows.Scan(
+			&o.OrderID, &o.Status, &o.Date, &o.ExpectedDate,
+			&o.Provider.ProviderID, &o.Provider.Name, &o.Provider.Email,
+			&o.Branch.BranchID, &o.Branch.Name,
+			&l.LineID, &l.Amount, &l.Received, &l.UnitCost,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(orders) == 0 || orders[len(orders)-1].OrderID != o.OrderID {
+			o.Lines = []models.PurchaseOrderLine{}
+			orders = append(orders, o)
+		}
+		last := &orders[len(orders)-1]
+		last.Lines = append(last.Lines, l)
+		last.Total = pricing.RoundCents(last.Total + l.UnitCost*float32(l.Amount))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// insertPurchaseOrder inserts a purchase order with the given status to be delivered at its branch, returns its id
+func insertPurchaseOrder(ctx context.Context, tx *sql.Tx, order models.PurchaseOrderDTO, status string) (int, error) {
+	err := checkProviderExists(ctx, tx, order.ProviderID)
+	if err != nil {
+		return 0, err
+	}
+
+	branchID := branchOrMain(order.BranchID)
+	err = checkBranchExists(ctx, tx, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	var orderID int
+	query := `
+		INSERT INTO orden_compra (id_proveedor, id_sucursal, estado, fecha, fecha_esperada)
+		VALUES ($1, $2, $3, CURRENT_DATE, $4) RETURNING id_orden;
+	`
+	err = tx.QueryRowContext(ctx, query, order.ProviderID, branchID, status, order.ExpectedDate).Scan(&orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertPurchaseLines(ctx, tx, orderID, order.Lines)
+	if err != nil {
+		return 0, err
+	}
+
+	return orderID, nil
+}
+
+// insertPurchaseLines inserts the lines of a purchase order, a line without unit cost takes the cost of the product
+// with the provider of the order or, without one, the provider price of the product
+func insertPurchaseLines(ctx context.Context, tx *sql.Tx, orderID int, lines []models.PurchaseOrderLineDTO) error {
+	query := `
+		INSERT INTO detalle_orden_compra (id_orden, id_producto, cantidad, costo_unitario)
+		SELECT $1, p.id_producto, $3, COALESCE(NULLIF($4::NUMERIC, 0), NULLIF(pp.costo, 0), p.precio_proveedor)
+		FROM producto p
+		LEFT JOIN orden_compra o
+			ON o.id_orden = $1
+		LEFT JOIN producto_proveedor pp
+			ON pp.id_producto = p.id_producto AND pp.id_proveedor = o.id_proveedor
+		WHERE p.id_producto = $2;
+	`
+	for _, line := range lines {
+		result, err := tx.ExecContext(ctx, query, orderID, line.ProductID, line.Amount, line.UnitCost)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return repository.ErrProductNotFound
+		}
+	}
+
+	return nil
+}
+
+// insertGoodsReceipt registers a shipment received against a purchase order, returns its id.
+//
+// Received units are added to the stock of the branch of the order and damaged ones to the damaged stock of each
+// product, whose provider price and cost with the provider of the order become the actual unit cost of the
+// shipment. Received units open a cost layer at that cost. The caller refreshes the status of the order afterwards.
+func insertGoodsReceipt(ctx context.Context, tx *sql.Tx, orderID int, receipt models.GoodsReceiptDTO) (int, error) {
+	var branchID int
+	query := `SELECT id_sucursal FROM orden_compra WHERE id_orden = $1;`
+	err := tx.QueryRowContext(ctx, query, orderID).Scan(&branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	var receiptID int
+	query = `INSERT INTO recepcion (id_orden, fecha, notas) VALUES ($1, CURRENT_TIMESTAMP, $2) RETURNING id_recepcion;`
+	err = tx.QueryRowContext(ctx, query, orderID, receipt.Notes).Scan(&receiptID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementDelivery, purchaseOrderReference(orderID), "")
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordPriceChange(ctx, tx, models.PriceChangeReceipt, purchaseOrderReference(orderID), "")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range receipt.Lines {
+		var productID, pending int
+		var orderCost float32
+		query = `
+			SELECT id_producto, cantidad - cantidad_recibida, costo_unitario
+			FROM detalle_orden_compra
+			WHERE id_detalle_orden = $1 AND id_orden = $2
+			FOR UPDATE;
+		`
+		err = tx.QueryRowContext(ctx, query, line.LineID, orderID).Scan(&productID, &pending, &orderCost)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, repository.ErrPurchaseLineNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		arrived := line.Received + line.Damaged
+		if arrived > pending {
+			return 0, repository.ErrReceiptExceedsOrder
+		}
+
+		unitCost := line.UnitCost
+		if unitCost == 0 {
+			unitCost = orderCost
+		}
+
+		query = `
+			INSERT INTO detalle_recepcion (id_recepcion, id_detalle_orden, cantidad, danado, rechazado, costo_unitario)
+			VALUES ($1, $2, $3, $4, $5, $6);
+		`
+		_, err = tx.ExecContext(ctx, query, receiptID, line.LineID, line.Received, line.Damaged, line.Rejected, unitCost)
+		if err != nil {
+			return 0, err
+		}
+
+		if arrived == 0 {
+			continue
+		}
+
+		query = `UPDATE detalle_orden_compra SET cantidad_recibida = cantidad_recibida + $1 WHERE id_detalle_orden = $2;`
+		_, err = tx.ExecContext(ctx, query, arrived, line.LineID)
+		if err != nil {
+			return 0, err
+		}
+
+		query = `
+			UPDATE producto
+			SET stock_danado = stock_danado + $1, precio_proveedor = $2
+			WHERE id_producto = $3;
+		`
+		_, err = tx.ExecContext(ctx, query, line.Damaged, unitCost, productID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = addBranchStock(ctx, tx, branchID, productID, line.Received)
+		if err != nil {
+			return 0, err
+		}
+
+		err = addCostLayer(ctx, tx, productID, line.Received, unitCost, purchaseOrderReference(orderID))
+		if err != nil {
+			return 0, err
+		}
+
+		query = `
+			UPDATE producto_proveedor
+			SET costo = $1
+			WHERE id_producto = $2 AND id_proveedor = (SELECT id_proveedor FROM orden_compra WHERE id_orden = $3);
+		`
+		_, err = tx.ExecContext(ctx, query, unitCost, productID, orderID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return receiptID, nil
+}
+
+// refreshPurchaseOrderStatus marks a sent purchase order as partially received or received after a receipt
+func refreshPurchaseOrderStatus(ctx context.Context, tx *sql.Tx, orderID int) error {
+	query := `
+		UPDATE orden_compra o
+		SET estado = CASE
+			WHEN NOT EXISTS (
+				SELECT 1 FROM detalle_orden_compra d WHERE d.id_orden = o.id_orden AND d.cantidad_recibida < d.cantidad
+			) THEN $2
+			WHEN EXISTS (
+				SELECT 1 FROM detalle_orden_compra d WHERE d.id_orden = o.id_orden AND d.cantidad_recibida > 0
+			) THEN $3
+			ELSE o.estado
+		END
+		WHERE o.id_orden = $1 AND o.estado IN ($4, $3);
+	`
+	_, err := tx.ExecContext(ctx, query, orderID, models.PurchaseReceived, models.PurchasePartial, models.PurchaseSent)
+	return err
+}
+
+// lockPurchaseOrder locks a purchase order, returns its status
+func lockPurchaseOrder(ctx context.Context, tx *sql.Tx, orderID int) (string, error) {
+	var status string
+	query := `SELECT estado FROM orden_compra WHERE id_orden = $1 FOR UPDATE;`
+	err := tx.QueryRowContext(ctx, query, orderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrPurchaseOrderNotFound
+	}
+
+	return status, err
+}
@@ -0,0 +1,280 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllQuotes fetches all quotes from database with their lines
+func (r *Repository) GetAllQuotes() ([]models.Quote, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return r.queryQuotes(ctx, 0)
+}
+
+// GetQuote fetches a single quote with its lines
+func (r *Repository) GetQuote(quoteID int) (models.Quote, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	quotes, err := r.queryQuotes(ctx, quoteID)
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	if len(quotes) == 0 {
+		return models.Quote{}, repository.ErrQuoteNotFound
+	}
+
+	return quotes[0], nil
+}
+
+// InsertQuote inserts a quote priced with the current catalog prices and promotions, returns its id
+func (r *Repository) InsertQuote(quote models.QuoteDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	products, err := catalogProducts(ctx, tx, quote.Lines)
+	if err != nil {
+		return 0, err
+	}
+
+	promotions, err := activePromotions(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	priced, tax := r.priceSale(models.SaleDTO{ClientID: quote.ClientID, Lines: quote.Lines}, products, promotions)
+
+	var quoteID int
+	query := `
+		INSERT INTO cotizacion (id_cliente, fecha, vigencia, estado, subtotal, iva, total)
+		VALUES ($1, CURRENT_DATE, $2, $3, $4, $5, $6) RETURNING id_cotizacion;
+	`
+	err = tx.QueryRowContext(ctx, query,
+		quote.ClientID,
+		quote.ValidUntil,
+		models.QuoteOpen,
+		priced.Subtotal,
+		tax,
+		priced.Total,
+	).Scan(&quoteID)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		INSERT INTO detalle_cotizacion (id_cotizacion, id_producto, cantidad, precio_unitario, descuento)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+	for _, line := range priced.Lines {
+		_, err = tx.ExecContext(ctx, query, quoteID, line.ProductID, line.Amount, line.UnitPrice, line.Discount)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return quoteID, nil
+}
+
+// CancelQuote cancels an open quote
+func (r *Repository) CancelQuote(quoteID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenQuote(ctx, tx, quoteID)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE cotizacion SET estado = $1 WHERE id_cotizacion = $2;`
+	_, err = tx.ExecContext(ctx, query, models.QuoteCancelled, quoteID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConvertQuote turns an open quote into a sale in one transaction.
+//
+// The sale goes through the same path as any other sale, so prices and stock are validated again at this moment
+// instead of trusting the ones of the quote.
+func (r *Repository) ConvertQuote(quoteID int, conversion models.ConvertQuoteDTO) (models.SaleReceipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SaleReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	clientID, err := lockOpenQuote(ctx, tx, quoteID)
+	if err != nil {
+		return models.SaleReceipt{}, err
+	}
+
+	query := `
+		SELECT id_producto, cantidad
+		FROM detalle_cotizacion
+		WHERE id_cotizacion = $1
+		ORDER BY id_detalle_cotizacion;
+	`
+	rows, err := tx.QueryContext(ctx, query, quoteID)
+	if err != nil {
+		return models.SaleReceipt{}, err
+	}
+
+	sale := models.SaleDTO{
+		SessionID: conversion.SessionID,
+		ClientID:  clientID,
+		OnCredit:  conversion.OnCredit,
+		Payments:  conversion.Payments,
+	}
+	for rows.Next() {
+		line := models.SaleLineDTO{}
+		err := rows.Scan(&line.ProductID, &line.Amount)
+		if err != nil {
+			rows.Close()
+			return models.SaleReceipt{}, err
+		}
+		sale.Lines = append(sale.Lines, line)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return models.SaleReceipt{}, err
+	}
+
+	receipt, err := r.insertSale(ctx, tx, sale)
+	if err != nil {
+		return receipt, err
+	}
+
+	query = `UPDATE cotizacion SET estado = $1, id_venta = $2 WHERE id_cotizacion = $3;`
+	_, err = tx.ExecContext(ctx, query, models.QuoteConverted, receipt.SaleID, quoteID)
+	if err != nil {
+		return receipt, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return receipt, err
+	}
+
+	return receipt, nil
+}
+
+// queryQuotes fetches the quote with the given id, or every quote when the id is 0
+func (r *Repository) queryQuotes(ctx context.Context, quoteID int) ([]models.Quote, error) {
+	quotes := []models.Quote{}
+	query := `
+		SELECT
+			q.id_cotizacion,
+			q.fecha,
+			q.vigencia,
+			CASE WHEN q.estado = $2 AND q.vigencia < CURRENT_DATE THEN $3 ELSE q.estado END,
+			q.subtotal,
+			q.iva,
+			q.total,
+			COALESCE(q.id_venta, 0),
+			c.id_cliente,
+			c.nombre_cliente,
+			c.direccion_cliente,
+			c.telefono_cliente,
+			d.cantidad,
+			d.precio_unitario,
+			d.descuento,
+			p.id_producto,
+			p.clasificacion,
+			p.marca
+		FROM cotizacion q
+		INNER JOIN cliente c
+			ON c.id_cliente = q.id_cliente
+		INNER JOIN detalle_cotizacion d
+			ON d.id_cotizacion = q.id_cotizacion
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		WHERE $1 = 0 OR q.id_cotizacion = $1
+		ORDER BY q.id_cotizacion, d.id_detalle_cotizacion;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, quoteID, models.QuoteOpen, models.QuoteExpired)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		q := models.Quote{}
+		l := models.QuoteLine{}
+		err := rows.Scan(
+			&q.QuoteID, &q.Date, &q.ValidUntil, &q.Status, &q.SubTotal, &q.Tax, &q.Total, &q.SaleID,
+			&q.Client.ClientID, &q.Client.Name, &q.Client.Address, &q.Client.Phone,
+			&l.Amount, &l.UnitPrice, &l.Discount,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(quotes) == 0 || quotes[len(quotes)-1].QuoteID != q.QuoteID {
+			q.Lines = []models.QuoteLine{}
+			quotes = append(quotes, q)
+		}
+		last := &quotes[len(quotes)-1]
+		last.Lines = append(last.Lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
+// lockOpenQuote locks a quote that is still open and valid, returns the client it was made for
+func lockOpenQuote(ctx context.Context, tx *sql.Tx, quoteID int) (int, error) {
+	var clientID int
+	var isOpen bool
+	query := `
+		SELECT id_cliente, estado = $2 AND vigencia >= CURRENT_DATE
+		FROM cotizacion
+		WHERE id_cotizacion = $1
+		FOR UPDATE;
+	`
+	err := tx.QueryRowContext(ctx, query, quoteID, models.QuoteOpen).Scan(&clientID, &isOpen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrQuoteNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !isOpen {
+		return 0, repository.ErrQuoteNotOpen
+	}
+
+	return clientID, nil
+}
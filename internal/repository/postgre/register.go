@@ -0,0 +1,336 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllRegisterSessions fetches all register sessions from database, newest first
+func (r *Repository) GetAllRegisterSessions() ([]models.RegisterSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	sessions := []models.RegisterSession{}
+	query := `
+		SELECT id_sesion, id_sucursal, cajero, fondo_inicial, estado, apertura, cierre
+		FROM sesion_caja
+		ORDER BY id_sesion DESC;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		s := models.RegisterSession{}
+		var closedAt sql.NullTime
+		err := rows.Scan(&s.SessionID, &s.BranchID, &s.Cashier, &s.OpeningFloat, &s.Status, &s.OpenedAt, &closedAt)
+		if err != nil {
+			return nil, err
+		}
+		if closedAt.Valid {
+			s.ClosedAt = &closedAt.Time
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// GetRegisterSession fetches a register session with its cash movements and, once closed, its counts
+func (r *Repository) GetRegisterSession(sessionID int) (models.RegisterSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	s := models.RegisterSession{Movements: []models.CashMovement{}, Counts: []models.RegisterCount{}}
+	query := `
+		SELECT id_sesion, id_sucursal, cajero, fondo_inicial, estado, apertura, cierre
+		FROM sesion_caja
+		WHERE id_sesion = $1;
+	`
+
+	var closedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, sessionID).Scan(
+		&s.SessionID, &s.BranchID, &s.Cashier, &s.OpeningFloat, &s.Status, &s.OpenedAt, &closedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, repository.ErrSessionNotFound
+	}
+	if err != nil {
+		return s, err
+	}
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+
+	query = `
+		SELECT id_movimiento, tipo, monto, concepto, fecha
+		FROM movimiento_caja
+		WHERE id_sesion = $1
+		ORDER BY id_movimiento;
+	`
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := models.CashMovement{}
+		err := rows.Scan(&m.MovementID, &m.Kind, &m.Amount, &m.Concept, &m.Date)
+		if err != nil {
+			return s, err
+		}
+		s.Movements = append(s.Movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return s, err
+	}
+
+	query = `
+		SELECT metodo, esperado, contado
+		FROM conteo_caja
+		WHERE id_sesion = $1
+		ORDER BY metodo;
+	`
+	rows, err = r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := models.RegisterCount{}
+		err := rows.Scan(&c.Method, &c.Expected, &c.Counted)
+		if err != nil {
+			return s, err
+		}
+		s.Counts = append(s.Counts, newRegisterCount(c.Method, c.Expected, c.Counted))
+	}
+
+	return s, rows.Err()
+}
+
+// OpenRegisterSession opens a register session for a cashier with a starting float at a branch, returns its id
+func (r *Repository) OpenRegisterSession(session models.OpenRegisterDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var isOpen bool
+	query := `SELECT EXISTS (SELECT 1 FROM sesion_caja WHERE cajero = $1 AND estado = $2);`
+	err = tx.QueryRowContext(ctx, query, session.Cashier, models.RegisterOpen).Scan(&isOpen)
+	if err != nil {
+		return 0, err
+	}
+
+	if isOpen {
+		return 0, repository.ErrSessionAlreadyOpen
+	}
+
+	branchID := branchOrMain(session.BranchID)
+	err = checkBranchExists(ctx, tx, branchID)
+	if err != nil {
+		return 0, err
+	}
+
+	var sessionID int
+	query = `
+		INSERT INTO sesion_caja (cajero, fondo_inicial, estado, apertura, id_sucursal)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4) RETURNING id_sesion;
+	`
+	err = tx.QueryRowContext(ctx, query, session.Cashier, session.OpeningFloat, models.RegisterOpen, branchID).Scan(&sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return sessionID, nil
+}
+
+// InsertCashMovement registers money put into or taken out of the drawer of an open register session
+func (r *Repository) InsertCashMovement(sessionID int, movement models.CashMovementDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOpenSession(ctx, tx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO movimiento_caja (id_sesion, tipo, monto, concepto, fecha)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP);
+	`
+	_, err = tx.ExecContext(ctx, query, sessionID, movement.Kind, movement.Amount, movement.Concept)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CloseRegisterSession closes a register session with the money counted per payment method.
+//
+// Expected cash is the starting float plus cash received minus change given, plus or minus cash movements and
+// minus the cash refunded on returns; any other method is expected to match what was charged with it.
+func (r *Repository) CloseRegisterSession(sessionID int, closing models.CloseRegisterDTO) (models.RegisterSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RegisterSession{}, err
+	}
+	defer tx.Rollback()
+
+	// Locking the session waits for sales still being registered on it
+	var status string
+	var openingFloat, cashIn, cashOut, refunded float32
+	query := `
+		SELECT
+			s.estado,
+			s.fondo_inicial,
+			COALESCE((SELECT SUM(monto) FROM movimiento_caja WHERE id_sesion = s.id_sesion AND tipo = $2), 0),
+			COALESCE((SELECT SUM(monto) FROM movimiento_caja WHERE id_sesion = s.id_sesion AND tipo = $3), 0),
+			COALESCE((SELECT SUM(efectivo) FROM devolucion WHERE id_sesion = s.id_sesion), 0)
+		FROM sesion_caja s
+		WHERE s.id_sesion = $1
+		FOR UPDATE;
+	`
+	err = tx.QueryRowContext(ctx, query, sessionID, models.CashIn, models.CashOut).Scan(
+		&status, &openingFloat, &cashIn, &cashOut, &refunded,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RegisterSession{}, repository.ErrSessionNotFound
+	}
+	if err != nil {
+		return models.RegisterSession{}, err
+	}
+
+	if status != models.RegisterOpen {
+		return models.RegisterSession{}, repository.ErrSessionClosed
+	}
+
+	expected := map[string]float32{models.PaymentCash: openingFloat + cashIn - cashOut - refunded}
+
+	query = `
+		SELECT metodo, SUM(monto - cambio)
+		FROM pago_venta
+		WHERE id_sesion = $1
+		GROUP BY metodo;
+	`
+	rows, err := tx.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return models.RegisterSession{}, err
+	}
+
+	for rows.Next() {
+		var method string
+		var amount float32
+		err := rows.Scan(&method, &amount)
+		if err != nil {
+			rows.Close()
+			return models.RegisterSession{}, err
+		}
+		expected[method] += amount
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return models.RegisterSession{}, err
+	}
+
+	methods := make([]string, 0, len(expected))
+	for method := range expected {
+		methods = append(methods, method)
+	}
+	for method := range closing.Counted {
+		if _, ok := expected[method]; !ok {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+
+	query = `
+		INSERT INTO conteo_caja (id_sesion, metodo, esperado, contado)
+		VALUES ($1, $2, $3, $4);
+	`
+	for _, method := range methods {
+		_, err = tx.ExecContext(ctx, query, sessionID, method, pricing.RoundCents(expected[method]), closing.Counted[method])
+		if err != nil {
+			return models.RegisterSession{}, err
+		}
+	}
+
+	query = `UPDATE sesion_caja SET estado = $1, cierre = CURRENT_TIMESTAMP WHERE id_sesion = $2;`
+	_, err = tx.ExecContext(ctx, query, models.RegisterClosed, sessionID)
+	if err != nil {
+		return models.RegisterSession{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.RegisterSession{}, err
+	}
+
+	return r.GetRegisterSession(sessionID)
+}
+
+// lockOpenSession share locks a register session so it can not be closed while the transaction uses it,
+// fails if the session does not exist or is not open. It returns the cashier of the session
+func lockOpenSession(ctx context.Context, tx *sql.Tx, sessionID int) (string, error) {
+	var status, cashier string
+	query := `SELECT estado, cajero FROM sesion_caja WHERE id_sesion = $1 FOR SHARE;`
+	err := tx.QueryRowContext(ctx, query, sessionID).Scan(&status, &cashier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrSessionNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if status != models.RegisterOpen {
+		return "", repository.ErrSessionClosed
+	}
+
+	return cashier, nil
+}
+
+// newRegisterCount builds the count of a payment method flagging any difference of at least one cent
+func newRegisterCount(method string, expected, counted float32) models.RegisterCount {
+	difference := pricing.RoundCents(counted - expected)
+	return models.RegisterCount{
+		Method:      method,
+		Expected:    expected,
+		Counted:     counted,
+		Difference:  difference,
+		Discrepancy: math.Abs(float64(difference)) >= 0.01,
+	}
+}
@@ -3,20 +3,28 @@ package postgre
 import (
 	"context"
 	"database/sql"
-	"sync"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
 )
 
-func NewRepository(pool *sql.DB) *Repository {
+// NewRepository builds the postgres repository, costing is the method the cost of goods sold is recorded with
+func NewRepository(pool *sql.DB, calculator pricing.Calculator, costing string) *Repository {
 	return &Repository{
-		db: pool,
+		db:         pool,
+		calculator: calculator,
+		costing:    costing,
 	}
 }
 
 type Repository struct {
-	db *sql.DB
+	db         *sql.DB
+	calculator pricing.Calculator
+	costing    string
 }
 
 // InsertProduct inserts a product into database
@@ -24,74 +32,176 @@ func (r *Repository) InsertProduct(product models.ProductDTO) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	branchID := branchOrMain(product.BranchID)
+	err = checkBranchExists(ctx, tx, branchID)
+	if err != nil {
+		return err
+	}
+
+	// The product starts without stock, its initial stock is put at the branch and enters the ledger as an
+	// adjustment
 	query := `
-		INSERT INTO producto (clasificacion, id_categoria, marca, precio_publico, precio_proveedor, stock)
-		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id_producto;
+		INSERT INTO producto (clasificacion, id_categoria, id_marca, precio_publico, precio_proveedor, stock, stock_minimo, stock_maximo)
+		VALUES ($1, $2, $3, $4, $5, 0, $6, $7) RETURNING id_producto;
 	`
 
 	var newID int
-	err := r.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		product.Classification,
 		product.CategoryID,
-		product.Brand,
+		product.BrandID,
 		product.PublicPrice,
 		product.ProviderPrice,
-		product.Amount,
+		product.MinStock,
+		product.MaxStock,
 	).Scan(&newID)
 	if err != nil {
 		return err
 	}
 
-	query = `
-		INSERT INTO producto_proveedor (id_producto, id_proveedor, fecha_entrega, cantidad_surtir)
-		VALUES ($1, $2, NULL, NULL);
-	`
-	_, err = r.db.ExecContext(ctx, query, newID, product.ProviderID)
+	err = recordStockMovement(ctx, tx, models.MovementAdjustment, "alta de producto", "")
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = addBranchStock(ctx, tx, branchID, newID, product.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = addCostLayer(ctx, tx, newID, product.Amount, product.ProviderPrice, "alta de producto")
+	if err != nil {
+		return err
+	}
+
+	err = linkProvider(ctx, tx, newID, product.ProviderID, product.ProviderPrice)
+	if err != nil {
+		return err
+	}
+
+	err = setPreferredProvider(ctx, tx, newID, product.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetAllProducts fetch all products from databases
-func (r *Repository) GetAllProducts() ([]models.Product, error) {
+// GetAllProducts fetch all products from databases that pass the filter, their stock is the total of all branches
+// and is also given per branch
+func (r *Repository) GetAllProducts(filter models.ProductFilter) ([]models.Product, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if filter.VehicleID != 0 {
+		err = checkVehicleExists(ctx, tx, filter.VehicleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	products, err := queryProducts(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, tx.Commit()
+}
+
+// queryProducts fetches the products that pass the filter.
+//
+// A code matches the SKU, part number, OEM number, a barcode or the part number of another brand the product is an
+// exact equivalent of. A vehicle matches the fitments of the same make and
+// model whose years overlap its own, and whose engine and trim are the same or cover all of them; a vehicle with no
+// engine or trim matches the fitments of every engine or trim. A category matches its products and the ones of
+// every category under it.
+func queryProducts(ctx context.Context, tx *sql.Tx, filter models.ProductFilter) ([]models.Product, error) {
 	products := []models.Product{}
 	query := `
 		SELECT
 			p.id_producto,
+			COALESCE(p.sku, ''),
+			COALESCE(p.numero_parte, ''),
+			COALESCE(p.numero_oem, ''),
 			p.clasificacion,
 			p.marca,
+			COALESCE(p.id_marca, 0),
 			p.precio_publico,
 			p.precio_proveedor,
 			p.stock,
+			p.stock_danado,
+			p.stock_minimo,
+			p.stock_maximo,
 			c.id_categoria,
 			c.nombre_categoria as categoria,
-			pr.codigo,
-			pr.nombre_proveedor,
-			pr.correo as correo_proveedor,
-			pr.telefono_proveedor as tel_proveedor
+			COALESCE(pr.codigo, 0),
+			COALESCE(pr.nombre_proveedor, ''),
+			COALESCE(pr.correo, '') as correo_proveedor,
+			COALESCE(pr.telefono_proveedor, '') as tel_proveedor
 		FROM producto p
 		INNER JOIN categoria c
 			ON c.id_categoria = p.id_categoria
-		INNER JOIN producto_proveedor pp
-			ON pp.id_producto = p.id_producto
-		INNER JOIN proveedor pr
-			ON pp.id_proveedor = pr.codigo;
+		LEFT JOIN producto_proveedor pp
+			ON pp.id_producto = p.id_producto AND pp.preferido
+		LEFT JOIN proveedor pr
+			ON pp.id_proveedor = pr.codigo
+		WHERE ($1 = '' OR p.id_producto IN (
+			SELECT id_producto FROM producto WHERE sku = $1
+			UNION SELECT id_producto FROM producto WHERE numero_parte = $1
+			UNION SELECT id_producto FROM producto WHERE numero_oem = $1
+			UNION SELECT id_producto FROM codigo_barras WHERE codigo = $1
+			UNION SELECT id_producto FROM equivalencia WHERE numero_externo = $1 AND tipo = $4
+		))
+		AND ($2 = 0 OR p.id_producto IN (
+			SELECT a.id_producto
+			FROM aplicacion a
+			INNER JOIN vehiculo v
+				ON v.id_vehiculo = a.id_vehiculo
+			INNER JOIN vehiculo s
+				ON s.id_vehiculo = $2
+			WHERE LOWER(v.marca) = LOWER(s.marca)
+				AND LOWER(v.modelo) = LOWER(s.modelo)
+				AND v.anio_inicio <= COALESCE(NULLIF($3, 0), s.anio_fin)
+				AND v.anio_fin >= COALESCE(NULLIF($3, 0), s.anio_inicio)
+				AND (v.motor = '' OR s.motor = '' OR LOWER(v.motor) = LOWER(s.motor))
+				AND (v.version = '' OR s.version = '' OR LOWER(v.version) = LOWER(s.version))
+		))
+		AND ($5 = 0 OR p.id_categoria IN (SELECT id_categoria FROM subcategorias($5)))
+		AND ($6 = 0 OR p.id_marca = $6)
+		ORDER BY p.id_producto;
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := tx.QueryContext(ctx, query,
+		filter.Code,
+		filter.VehicleID,
+		filter.Year,
+		models.EquivalenceExact,
+		filter.CategoryID,
+		filter.BrandID,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		p := models.Product{}
 		err := rows.Scan(
-			&p.ProductID, &p.Classification, &p.Brand, &p.PublicPrice, &p.ProviderPrice, &p.Amount,
+			&p.ProductID, &p.SKU, &p.PartNumber, &p.OEMNumber,
+			&p.Classification, &p.Brand, &p.BrandID, &p.PublicPrice, &p.ProviderPrice, &p.Amount, &p.Damaged,
+			&p.MinStock, &p.MaxStock,
 			&p.Category.CategoryID, &p.Category.Name,
 			&p.Provider.ProviderID, &p.Provider.Name, &p.Provider.Email, &p.Provider.Phone,
 		)
@@ -105,86 +215,131 @@ func (r *Repository) GetAllProducts() ([]models.Product, error) {
 		return nil, err
 	}
 
-	return products, nil
-}
+	// A lookup matches a handful of products, so their details are read one by one instead of for every product
+	productIDs := []int{0}
+	if filter.Code != "" {
+		productIDs = productIDs[:0]
+		for _, p := range products {
+			productIDs = append(productIDs, p.ProductID)
+		}
+	}
 
-// UpdateProduct updates a product in database
-func (r *Repository) UpdateProduct(productID int, product models.ProductDTO) (int64, error) {
-	wg := sync.WaitGroup{}
-	errChan := make(chan error)
-	resultChan := make(chan sql.Result)
-	defer close(errChan)
-	defer close(resultChan)
-
-	wg.Add(1)
-
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-		defer cancel()
-
-		/*
-			From GUI product stock is not directly modified, but due to a database trigger, stock column needs to receive
-			a value not equal to NULL not to activate the trigger.
-		*/
-		query := `
-			UPDATE
-				producto
-			SET
-				clasificacion = $1,
-				marca = $2,
-				id_categoria = $3,
-				precio_publico = $4,
-			    precio_proveedor = $5,
-			    stock = $6
-			WHERE
-				id_producto = $7;
-		`
-		result, err := r.db.ExecContext(ctx, query,
-			product.Classification,
-			product.Brand,
-			product.CategoryID,
-			product.PublicPrice,
-			product.ProviderPrice,
-			product.Amount,
-			productID,
-		)
-		wg.Wait()
+	for _, productID := range productIDs {
+		links, err := queryProductProviders(ctx, tx, productID)
 		if err != nil {
-			errChan <- err
+			return nil, err
 		}
-		resultChan <- result
-	}()
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-		defer cancel()
+		stock, inTransit, err := queryBranchStock(ctx, tx, productID)
+		if err != nil {
+			return nil, err
+		}
 
-		query := `UPDATE producto_proveedor SET id_proveedor = $1 WHERE id_producto = $2`
-		_, err := r.db.ExecContext(ctx, query, product.ProviderID, productID)
+		barcodes, err := queryBarcodes(ctx, tx, productID)
 		if err != nil {
-			errChan <- err
+			return nil, err
 		}
-		wg.Done()
-	}()
 
-	var numRows int64
-	select {
-	case err := <-errChan:
-		return 0, err
-	case result := <-resultChan:
-		rows, err := result.RowsAffected()
+		alternatives, err := queryCrossReferences(ctx, tx, productID, true)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
-		numRows = rows
-		break
+
+		for i := range products {
+			id := products[i].ProductID
+			if productID != 0 && id != productID {
+				continue
+			}
+			products[i].Providers = links[id]
+			products[i].Stock = stock[id]
+			products[i].InTransit = inTransit[id]
+			products[i].Barcodes = barcodes[id]
+			products[i].Alternatives = alternatives[id]
+		}
+	}
+
+	return products, nil
+}
+
+// UpdateProduct updates a product in database and makes the given provider its preferred one, returns 0 rows when
+// the product does not exist
+func (r *Repository) UpdateProduct(productID int, product models.ProductDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	if numRows == 0 {
+	err = checkProductExists(ctx, tx, productID)
+	if errors.Is(err, repository.ErrProductNotFound) {
 		return 0, nil
 	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkProviderExists(ctx, tx, product.ProviderID)
+	if err != nil {
+		return 0, err
+	}
 
-	return numRows, nil
+	/*
+		From GUI product stock is not directly modified, but due to a database trigger, stock column needs to receive
+		a value not equal to NULL not to activate the trigger. Stock is kept as it is, it only changes through sales,
+		returns, receipts and inventory counts.
+	*/
+	query := `
+		UPDATE
+			producto
+		SET
+			clasificacion = $1,
+			id_marca = $2,
+			id_categoria = $3,
+			precio_publico = $4,
+		    precio_proveedor = $5,
+		    stock = stock,
+		    stock_minimo = $6,
+		    stock_maximo = $7
+		WHERE
+			id_producto = $8;
+	`
+	result, err := tx.ExecContext(ctx, query,
+		product.Classification,
+		product.BrandID,
+		product.CategoryID,
+		product.PublicPrice,
+		product.ProviderPrice,
+		product.MinStock,
+		product.MaxStock,
+		productID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = linkProvider(ctx, tx, productID, product.ProviderID, product.ProviderPrice)
+	if err != nil {
+		return 0, err
+	}
+
+	err = setPreferredProvider(ctx, tx, productID, product.ProviderID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
 }
 
 // DeleteProduct deletes a product from the database
@@ -336,25 +491,47 @@ func (r *Repository) DeleteProvider(providerID int) (int64, error) {
 	return rows, nil
 }
 
-// GetAllSales fetches all sales stored in database
+// GetAllSales fetches all sales stored in database with their lines and payments
 func (r *Repository) GetAllSales() ([]models.Sale, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
 	sales := []models.Sale{}
 	query := `
-		SELECT 
+		SELECT
 			v.id_venta,
 			v.fecha,
+			v.estado,
+			v.subtotal,
+			v.iva,
 			v.total,
-			v.cantidad_vendida,
+			v.precio_autorizado,
+			v.a_credito,
+			COALESCE((SELECT SUM(dv.reembolso) FROM devolucion dv WHERE dv.id_venta = v.id_venta), 0),
+			COALESCE(c.id_cliente, 0),
+			COALESCE(c.nombre_cliente, ''),
+			d.id_detalle,
+			d.cantidad,
+			COALESCE((SELECT SUM(dd.cantidad) FROM detalle_devolucion dd WHERE dd.id_detalle = d.id_detalle), 0),
+			d.precio_unitario,
+			d.descuento,
+			d.costo,
+			COALESCE(pm.id_promocion, 0),
+			COALESCE(pm.nombre, ''),
 			p.id_producto,
 			p.clasificacion,
 			p.marca,
-		    p.precio_publico
+			p.precio_publico
 		FROM venta v
-		INNER JOIN producto p 
-			ON v.id_producto = p.id_producto;
+		LEFT JOIN cliente c
+			ON c.id_cliente = v.id_cliente
+		INNER JOIN detalle_venta d
+			ON d.id_venta = v.id_venta
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		LEFT JOIN promocion pm
+			ON pm.id_promocion = d.id_promocion
+		ORDER BY v.id_venta, d.id_detalle;
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -364,59 +541,257 @@ func (r *Repository) GetAllSales() ([]models.Sale, error) {
 
 	for rows.Next() {
 		s := models.Sale{}
+		l := models.SaleLine{}
 		err := rows.Scan(
-			&s.SaleID, &s.Date, &s.Total, &s.Amount,
-			&s.Product.ProductID, &s.Product.Classification, &s.Product.Brand, &s.Product.PublicPrice,
+			&s.SaleID, &s.Date, &s.Status, &s.SubTotal, &s.Tax, &s.Total, &s.ManagerOverride, &s.OnCredit, &s.Refunded,
+			&s.Client.ClientID, &s.Client.Name,
+			&l.LineID, &l.Amount, &l.Returned, &l.UnitPrice, &l.Discount, &l.Cost,
+			&l.Promotion.PromotionID, &l.Promotion.Name,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand, &l.Product.PublicPrice,
 		)
 		if err != nil {
 			return nil, err
 		}
-		sales = append(sales, s)
+
+		// Rows come ordered by sale, so a line either belongs to the last sale or starts a new one
+		if len(sales) == 0 || sales[len(sales)-1].SaleID != s.SaleID {
+			s.Lines = []models.SaleLine{}
+			sales = append(sales, s)
+		}
+		last := &sales[len(sales)-1]
+		last.Lines = append(last.Lines, l)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
+	err = r.attachPayments(ctx, sales)
+	if err != nil {
+		return nil, err
+	}
+
 	return sales, nil
 }
 
-// InsertSale inserts a sale in database
-func (r *Repository) InsertSale(sale models.SaleDTO) error {
+// InsertSale inserts a sale with its lines and payments in database discounting their stock, returns the
+// receipt of the new sale
+func (r *Repository) InsertSale(sale models.SaleDTO) (models.SaleReceipt, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	query := `
-		INSERT INTO venta (id_producto, id_cliente, fecha, subtotal, total, cantidad_vendida)
-		VALUES ($1, $2, CURRENT_DATE, $3, $4, $5);
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SaleReceipt{}, err
+	}
+	defer tx.Rollback()
+
+	receipt, err := r.insertSale(ctx, tx, sale)
+	if err != nil {
+		return receipt, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return receipt, err
+	}
+
+	return receipt, nil
+}
+
+// insertSale inserts a sale inside the given transaction, so other documents can be turned into sales atomically
+func (r *Repository) insertSale(ctx context.Context, tx *sql.Tx, sale models.SaleDTO) (models.SaleReceipt, error) {
+	receipt := models.SaleReceipt{}
+
+	cashier, err := lockOpenSession(ctx, tx, sale.SessionID)
+	if err != nil {
+		return receipt, err
+	}
+
+	// Sales take stock from the branch of the register
+	var branchID int
+	query := `SELECT id_sucursal FROM sesion_caja WHERE id_sesion = $1;`
+	err = tx.QueryRowContext(ctx, query, sale.SessionID).Scan(&branchID)
+	if err != nil {
+		return receipt, err
+	}
+
+	// The id is taken before inserting the sale so the stock movements can reference it
+	query = `SELECT nextval(pg_get_serial_sequence('venta', 'id_venta'));`
+	err = tx.QueryRowContext(ctx, query).Scan(&receipt.SaleID)
+	if err != nil {
+		return receipt, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementSale, saleReference(receipt.SaleID), cashier)
+	if err != nil {
+		return receipt, err
+	}
+
+	managerID, err := checkManagerOverride(ctx, tx, sale.ManagerOverride)
+	if err != nil {
+		return receipt, err
+	}
+
+	products, err := reserveStock(ctx, tx, branchID, sale.Lines)
+	if err != nil {
+		return receipt, err
+	}
+
+	promotions, err := activePromotions(ctx, tx)
+	if err != nil {
+		return receipt, err
+	}
+	sale, tax := r.priceSale(sale, products, promotions)
+
+	if sale.OnCredit {
+		// Only what is not paid right now goes to the credit of the client
+		financed := sale.Total
+		for _, payment := range sale.Payments {
+			financed -= payment.Amount
+		}
+
+		err = checkCredit(ctx, tx, sale.ClientID, pricing.RoundCents(financed))
+		if err != nil {
+			return receipt, err
+		}
+	}
+
+	query = `
+		INSERT INTO venta
+			(id_venta, id_sesion, id_sucursal, id_cliente, fecha, subtotal, iva, total, precio_autorizado, id_gerente, a_credito, estado)
+		VALUES ($1, $2, $3, $4, CURRENT_DATE, $5, $6, $7, $8, NULLIF($9, 0), $10, $11);
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		sale.ProductID,
+	_, err = tx.ExecContext(ctx, query,
+		receipt.SaleID,
+		sale.SessionID,
+		branchID,
 		sale.ClientID,
 		sale.Subtotal,
+		tax,
 		sale.Total,
-		sale.Amount,
+		managerID != 0,
+		managerID,
+		sale.OnCredit,
+		models.SaleStatusPending,
 	)
 	if err != nil {
-		return err
+		return receipt, err
 	}
 
-	return nil
+	err = r.insertSaleLines(ctx, tx, receipt.SaleID, sale.Lines)
+	if err != nil {
+		return receipt, err
+	}
+
+	receipt.SubTotal = sale.Subtotal
+	receipt.Tax = tax
+	receipt.Total = sale.Total
+
+	return applyPayments(ctx, tx, sale.SessionID, receipt, sale.Payments)
 }
 
-// UpdateSale updates a sale in database
+// UpdateSale updates a sale in database, its lines are replaced by the incoming ones. A credit sale can not grow
+// over the credit limit of its client
 func (r *Repository) UpdateSale(saleId int, sale models.SaleDTO) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	query := `
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var branchID, clientID int
+	var onCredit bool
+	var status string
+	var total float32
+	query := `SELECT id_venta, id_sucursal, COALESCE(id_cliente, 0), a_credito, estado, total FROM venta WHERE id_venta = $1 FOR UPDATE;`
+	err = tx.QueryRowContext(ctx, query, saleId).Scan(&saleId, &branchID, &clientID, &onCredit, &status, &total)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkSaleWithoutReturns(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkSaleWithoutPayments(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkSaleNotInvoiced(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	managerID, err := checkManagerOverride(ctx, tx, sale.ManagerOverride)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementSale, saleReference(saleId), "")
+	if err != nil {
+		return 0, err
+	}
+
+	err = releaseStock(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM detalle_venta WHERE id_venta = $1;`, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	products, err := reserveStock(ctx, tx, branchID, sale.Lines)
+	if err != nil {
+		return 0, err
+	}
+
+	promotions, err := activePromotions(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	sale, tax := r.priceSale(sale, products, promotions)
+
+	if onCredit {
+		// The sale is already in the balance of its client, only what it grows by is new credit
+		financed := sale.Total
+		if clientID == sale.ClientID && status == models.SaleStatusPending {
+			financed -= total
+		}
+
+		if pricing.RoundCents(financed) > 0 {
+			err = checkCredit(ctx, tx, sale.ClientID, pricing.RoundCents(financed))
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	query = `
 		UPDATE venta
-		SET id_producto = $1, total = $2, cantidad_vendida = $3
-		WHERE id_venta = $4;
+		SET id_cliente = $1, subtotal = $2, iva = $3, total = $4, precio_autorizado = $5, id_gerente = NULLIF($6, 0)
+		WHERE id_venta = $7;
 	`
 
-	result, err := r.db.ExecContext(ctx, query, sale.ProductID, sale.Total, sale.Amount, saleId)
+	result, err := tx.ExecContext(ctx, query,
+		sale.ClientID,
+		sale.Subtotal,
+		tax,
+		sale.Total,
+		managerID != 0,
+		managerID,
+		saleId,
+	)
 	if err != nil {
 		return 0, err
 	}
@@ -426,17 +801,58 @@ func (r *Repository) UpdateSale(saleId int, sale models.SaleDTO) (int64, error)
 		return 0, err
 	}
 
+	err = r.insertSaleLines(ctx, tx, saleId, sale.Lines)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return rows, nil
 }
 
-// DeleteSale deletes a sale in database
+// DeleteSale deletes a sale in database giving its units back to stock, its lines are removed on cascade. A sale
+// with payments can not be deleted, so the history of register sessions and client credit is kept
 func (r *Repository) DeleteSale(saleId int) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = checkSaleWithoutReturns(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkSaleWithoutPayments(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkSaleNotInvoiced(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementSale, saleReference(saleId), "")
+	if err != nil {
+		return 0, err
+	}
+
+	err = releaseStock(ctx, tx, saleId)
+	if err != nil {
+		return 0, err
+	}
+
 	query := `DELETE FROM venta WHERE id_venta = $1`
 
-	result, err := r.db.ExecContext(ctx, query, saleId)
+	result, err := tx.ExecContext(ctx, query, saleId)
 	if err != nil {
 		return 0, err
 	}
@@ -446,10 +862,132 @@ func (r *Repository) DeleteSale(saleId int) (int64, error) {
 		return 0, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return rows, nil
 }
 
-// GetAllDeliveries brings all the deliveries from database
+// priceSale sets unit prices, promotion discounts and totals of a sale from the catalog and returns the sale
+// with its tax.
+//
+// Prices and discounts sent by the client are only kept when a manager authorized them, and then no promotion is
+// applied over them. Totals are always computed from the lines.
+func (r *Repository) priceSale(sale models.SaleDTO, products map[int]models.Product, promotions []models.Promotion) (models.SaleDTO, float32) {
+	lines := make([]models.SaleLineDTO, len(sale.Lines))
+	for i, line := range sale.Lines {
+		line.PromotionID = 0
+		if sale.ManagerOverride == nil {
+			line.UnitPrice = products[line.ProductID].PublicPrice
+			line.Discount = 0
+		}
+		lines[i] = line
+	}
+	sale.Lines = lines
+
+	if sale.ManagerOverride == nil {
+		pricing.ApplyPromotions(sale.Lines, products, sale.ClientID, promotions, time.Now())
+	}
+
+	subtotal, tax, total := r.calculator.Totals(sale.Lines)
+	sale.Subtotal = subtotal
+	sale.Total = total
+
+	return sale, tax
+}
+
+// checkManagerOverride checks the PIN of the manager authorizing the prices of a sale against its hash and returns
+// the id of the manager, 0 when there is no override. It fails with repository.ErrManagerNotAuthorized when the
+// manager does not exist, is not active or the PIN does not match
+func checkManagerOverride(ctx context.Context, tx *sql.Tx, override *models.ManagerOverrideDTO) (int, error) {
+	if override == nil {
+		return 0, nil
+	}
+
+	var authorized bool
+	query := `SELECT EXISTS (SELECT 1 FROM gerente WHERE id_gerente = $1 AND activo AND pin = crypt($2, pin));`
+	err := tx.QueryRowContext(ctx, query, override.ManagerID, override.PIN).Scan(&authorized)
+	if err != nil {
+		return 0, err
+	}
+
+	if !authorized {
+		return 0, repository.ErrManagerNotAuthorized
+	}
+
+	return override.ManagerID, nil
+}
+
+// checkSaleWithoutReturns fails with repository.ErrSaleHasReturns when a sale already has returns registered
+func checkSaleWithoutReturns(ctx context.Context, tx *sql.Tx, saleID int) error {
+	var hasReturns bool
+	query := `SELECT EXISTS (SELECT 1 FROM devolucion WHERE id_venta = $1);`
+	err := tx.QueryRowContext(ctx, query, saleID).Scan(&hasReturns)
+	if err != nil {
+		return err
+	}
+
+	if hasReturns {
+		return repository.ErrSaleHasReturns
+	}
+
+	return nil
+}
+
+// checkSaleWithoutPayments fails with repository.ErrSaleHasPayments when a sale already received payments
+func checkSaleWithoutPayments(ctx context.Context, tx *sql.Tx, saleID int) error {
+	var hasPayments bool
+	query := `SELECT EXISTS (SELECT 1 FROM pago_venta WHERE id_venta = $1);`
+	err := tx.QueryRowContext(ctx, query, saleID).Scan(&hasPayments)
+	if err != nil {
+		return err
+	}
+
+	if hasPayments {
+		return repository.ErrSaleHasPayments
+	}
+
+	return nil
+}
+
+// insertSaleLines inserts the lines of a sale inside the given transaction, taking the cost of their units out of
+// the cost layers
+func (r *Repository) insertSaleLines(ctx context.Context, tx *sql.Tx, saleID int, lines []models.SaleLineDTO) error {
+	for _, line := range lines {
+		var lineID int
+		query := `
+			INSERT INTO detalle_venta (id_venta, id_producto, cantidad, precio_unitario, descuento, id_promocion)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0)) RETURNING id_detalle;
+		`
+		err := tx.QueryRowContext(ctx, query,
+			saleID,
+			line.ProductID,
+			line.Amount,
+			line.UnitPrice,
+			line.Discount,
+			line.PromotionID,
+		).Scan(&lineID)
+		if err != nil {
+			return err
+		}
+
+		cost, err := r.consumeCost(ctx, tx, line.ProductID, line.Amount, lineID, saleReference(saleID))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE detalle_venta SET costo = $1 WHERE id_detalle = $2;`, cost, lineID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAllDeliveries brings the pending deliveries from database, every line of a sent purchase order that was not
+// fully received
 func (r *Repository) GetAllDeliveries() ([]models.Delivery, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -457,6 +995,7 @@ func (r *Repository) GetAllDeliveries() ([]models.Delivery, error) {
 	deliveries := []models.Delivery{}
 	query := `
 		SELECT
+			o.id_orden,
 		    po.id_producto,
 			po.clasificacion,
 			po.marca,
@@ -464,27 +1003,32 @@ func (r *Repository) GetAllDeliveries() ([]models.Delivery, error) {
 		    pr.codigo,
 			pr.nombre_proveedor,
 			pr.correo,
-			pp.fecha_entrega,
-			pp.cantidad_surtir
+			o.fecha_esperada,
+			d.cantidad - d.cantidad_recibida
 		FROM 
-			producto_proveedor pp
+			detalle_orden_compra d
+		INNER JOIN orden_compra o
+			ON o.id_orden = d.id_orden
 		INNER JOIN producto po
-			ON pp.id_producto = po.id_producto
+			ON d.id_producto = po.id_producto
 		INNER JOIN proveedor pr
-			ON pp.id_proveedor = pr.codigo
+			ON o.id_proveedor = pr.codigo
 		INNER JOIN categoria c 
 		    ON po.id_categoria = c.id_categoria
-		WHERE pp.fecha_entrega IS NOT NULL;
+		WHERE o.estado IN ($1, $2) AND d.cantidad_recibida < d.cantidad
+		ORDER BY o.fecha_esperada, o.id_orden;
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, models.PurchaseSent, models.PurchasePartial)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		d := models.Delivery{}
 		err := rows.Scan(
+			&d.OrderID,
 			&d.Product.ProductID, &d.Product.Classification, &d.Product.Brand, &d.Product.Category.Name,
 			&d.Provider.ProviderID, &d.Provider.Name, &d.Provider.Email,
 			&d.DeliveryDate, &d.Amount,
@@ -502,57 +1046,110 @@ func (r *Repository) GetAllDeliveries() ([]models.Delivery, error) {
 	return deliveries, nil
 }
 
-// InsertDelivery inserts a delivery in database
+// InsertDelivery registers a pending delivery as a purchase order of a single product already sent to the provider,
+// no rows are affected when the product, the provider or the branch do not exist
 func (r *Repository) InsertDelivery(delivery models.DeliveryDTO) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	query := `
-		UPDATE producto_proveedor
-		SET fecha_entrega = $1, cantidad_surtir = $2
-		WHERE id_producto = $3 AND id_proveedor = $4;
-	`
-
-	result, err := r.db.ExecContext(ctx, query,
-		delivery.DeliveryDate,
-		delivery.Amount,
-		delivery.ProductID,
-		delivery.ProviderID,
-	)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	order := models.PurchaseOrderDTO{
+		ProviderID:   delivery.ProviderID,
+		BranchID:     delivery.BranchID,
+		ExpectedDate: delivery.DeliveryDate,
+		Lines:        []models.PurchaseOrderLineDTO{{ProductID: delivery.ProductID, Amount: delivery.Amount}},
+	}
+	_, err = insertPurchaseOrder(ctx, tx, order, models.PurchaseSent)
+	if errors.Is(err, repository.ErrProviderNotFound) || errors.Is(err, repository.ErrProductNotFound) ||
+		errors.Is(err, repository.ErrBranchNotFound) {
+		return 0, nil
+	}
 	if err != nil {
 		return 0, err
 	}
 
-	return rows, nil
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
 }
 
-// DeleteDelivery "deletes" a delivery in frontend perspective, it just updates some fields to NULL
+// DeleteDelivery "deletes" a delivery in frontend perspective, it receives in full every pending unit of the
+// product ordered to the provider, returns the number of purchase order lines received
 func (r *Repository) DeleteDelivery(productID, providerID int) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	query := `
-		UPDATE producto_proveedor
-		SET fecha_entrega = NULL, cantidad_surtir = NULL
-		WHERE id_producto = $1 AND id_proveedor = $2; 
+		SELECT o.id_orden, d.id_detalle_orden, d.cantidad - d.cantidad_recibida
+		FROM detalle_orden_compra d
+		INNER JOIN orden_compra o
+			ON o.id_orden = d.id_orden
+		WHERE d.id_producto = $1 AND o.id_proveedor = $2 AND o.estado IN ($3, $4) AND d.cantidad_recibida < d.cantidad
+		ORDER BY o.id_orden, d.id_detalle_orden
+		FOR UPDATE OF o;
 	`
-
-	result, err := r.db.ExecContext(ctx, query, productID, providerID)
+	rows, err := tx.QueryContext(ctx, query, productID, providerID, models.PurchaseSent, models.PurchasePartial)
 	if err != nil {
 		return 0, err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
+	var orderIDs []int
+	receipts := make(map[int]models.GoodsReceiptDTO)
+	for rows.Next() {
+		var orderID int
+		line := models.GoodsReceiptLineDTO{}
+		err := rows.Scan(&orderID, &line.LineID, &line.Received)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		receipt, ok := receipts[orderID]
+		if !ok {
+			orderIDs = append(orderIDs, orderID)
+			receipt.Notes = "Entrega dada de alta"
+		}
+		receipt.Lines = append(receipt.Lines, line)
+		receipts[orderID] = receipt
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
 		return 0, err
 	}
 
-	return rows, nil
+	var received int64
+	for _, orderID := range orderIDs {
+		_, err = insertGoodsReceipt(ctx, tx, orderID, receipts[orderID])
+		if err != nil {
+			return 0, err
+		}
+
+		err = refreshPurchaseOrderStatus(ctx, tx, orderID)
+		if err != nil {
+			return 0, err
+		}
+		received += int64(len(receipts[orderID].Lines))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return received, nil
 }
 
 // GetAllClients fetches all clients from database
@@ -563,12 +1160,19 @@ func (r *Repository) GetAllClients() ([]models.Client, error) {
 	clients := []models.Client{}
 	query := `
 		 SELECT 
-			id_cliente,
-		    nombre_cliente,
-		    direccion_cliente,
-		    telefono_cliente
+			c.id_cliente,
+		    c.nombre_cliente,
+		    c.direccion_cliente,
+		    c.telefono_cliente,
+		    c.limite_credito,
+		    ` + fmt.Sprintf(clientBalanceQuery, "c.id_cliente") + `,
+		    c.rfc,
+		    c.razon_social,
+		    c.regimen_fiscal,
+		    c.codigo_postal_fiscal,
+		    c.uso_cfdi
 		FROM
-			cliente;
+			cliente c;
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -578,10 +1182,23 @@ func (r *Repository) GetAllClients() ([]models.Client, error) {
 
 	for rows.Next() {
 		c := models.Client{}
-		err := rows.Scan(&c.ClientID, &c.Name, &c.Address, &c.Phone)
+		var rfc, legalName, taxRegime, zipCode, cfdiUse sql.NullString
+		err := rows.Scan(
+			&c.ClientID, &c.Name, &c.Address, &c.Phone, &c.CreditLimit, &c.Balance,
+			&rfc, &legalName, &taxRegime, &zipCode, &cfdiUse,
+		)
 		if err != nil {
 			return nil, err
 		}
+		if rfc.Valid {
+			c.TaxData = &models.TaxDataDTO{
+				RFC:       rfc.String,
+				LegalName: legalName.String,
+				TaxRegime: taxRegime.String,
+				ZipCode:   zipCode.String,
+				CfdiUse:   cfdiUse.String,
+			}
+		}
 		clients = append(clients, c)
 	}
 
@@ -598,10 +1215,10 @@ func (r *Repository) InsertClient(client models.ClientDTO) error {
 	defer cancel()
 
 	query := `
-		INSERT INTO cliente (nombre_cliente, telefono_cliente, direccion_cliente)
-		VALUES ($1, $2, $3);
+		INSERT INTO cliente (nombre_cliente, telefono_cliente, direccion_cliente, limite_credito)
+		VALUES ($1, $2, $3, $4);
 `
-	_, err := r.db.ExecContext(ctx, query, client.Name, client.Phone, client.Address)
+	_, err := r.db.ExecContext(ctx, query, client.Name, client.Phone, client.Address, client.CreditLimit)
 	if err != nil {
 		return err
 	}
@@ -616,11 +1233,11 @@ func (r *Repository) UpdateClient(cliendId int, client models.ClientDTO) (int64,
 
 	query := `
 		UPDATE cliente
-		SET nombre_cliente = $1, telefono_cliente = $2, direccion_cliente = $3
-		WHERE id_cliente = $4;
+		SET nombre_cliente = $1, telefono_cliente = $2, direccion_cliente = $3, limite_credito = $4
+		WHERE id_cliente = $5;
 	`
 
-	result, err := r.db.ExecContext(ctx, query, client.Name, client.Phone, client.Address, cliendId)
+	result, err := r.db.ExecContext(ctx, query, client.Name, client.Phone, client.Address, client.CreditLimit, cliendId)
 	if err != nil {
 		return 0, err
 	}
@@ -652,50 +1269,42 @@ func (r *Repository) DeleteClient(clientId int) (int64, error) {
 	return rows, nil
 }
 
-// GetAllBrands brings all the brands from providers from database without duplicates
-func (r *Repository) GetAllBrands() ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
-
-	brands := []string{}
-	query := `SELECT DISTINCT empresa FROM proveedor;`
-
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-
-	for rows.Next() {
-		brand := ""
-		err := rows.Scan(&brand)
-		if err != nil {
-			return nil, err
-		}
-		brands = append(brands, brand)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return brands, nil
-}
-
-// GetAllCategories fetches all categories from database
+// GetAllCategories fetches all categories from database ordered as a tree, every category right after its parent
 func (r *Repository) GetAllCategories() ([]models.Category, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
 	categories := []models.Category{}
-	query := `SELECT id_categoria, nombre_categoria FROM categoria ORDER BY id_categoria;`
+	query := `
+		WITH RECURSIVE arbol AS (
+			SELECT id_categoria, nombre_categoria, id_padre, nombre_categoria::TEXT AS ruta, ARRAY[LOWER(nombre_categoria)] AS orden
+			FROM categoria
+			WHERE id_padre IS NULL
+			UNION ALL
+			SELECT c.id_categoria, c.nombre_categoria, c.id_padre, a.ruta || ' > ' || c.nombre_categoria, a.orden || LOWER(c.nombre_categoria)
+			FROM categoria c
+			INNER JOIN arbol a
+				ON c.id_padre = a.id_categoria
+		)
+		SELECT
+			a.id_categoria,
+			a.nombre_categoria,
+			COALESCE(a.id_padre, 0),
+			a.ruta,
+			(SELECT COUNT(*) FROM producto p WHERE p.id_categoria = a.id_categoria)
+		FROM arbol a
+		ORDER BY a.orden;
+	`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		category := models.Category{}
-		err := rows.Scan(&category.CategoryID, &category.Name)
+		err := rows.Scan(&category.CategoryID, &category.Name, &category.ParentID, &category.Path, &category.Products)
 		if err != nil {
 			return nil, err
 		}
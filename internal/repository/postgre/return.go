@@ -0,0 +1,295 @@
+package postgre
+
+import (
+	"context"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/pricing"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllReturns fetches all sale returns stored in database with their lines
+func (r *Repository) GetAllReturns() ([]models.Return, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	returns := []models.Return{}
+	query := `
+		SELECT
+			dv.id_devolucion,
+			dv.id_venta,
+			dv.fecha,
+			dv.motivo,
+			dv.reembolso,
+			dv.efectivo,
+			COALESCE(dv.id_sesion, 0),
+			dd.id_detalle,
+			dd.cantidad,
+			dd.danado,
+			dd.reembolso,
+			p.id_producto,
+			p.clasificacion,
+			p.marca
+		FROM devolucion dv
+		INNER JOIN detalle_devolucion dd
+			ON dd.id_devolucion = dv.id_devolucion
+		INNER JOIN detalle_venta d
+			ON d.id_detalle = dd.id_detalle
+		INNER JOIN producto p
+			ON p.id_producto = d.id_producto
+		ORDER BY dv.id_devolucion, dd.id_detalle_devolucion;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		ret := models.Return{}
+		l := models.ReturnLine{}
+		err := rows.Scan(
+			&ret.ReturnID, &ret.SaleID, &ret.Date, &ret.Reason, &ret.Refund, &ret.Cash, &ret.SessionID,
+			&l.LineID, &l.Amount, &l.Damaged, &l.Refund,
+			&l.Product.ProductID, &l.Product.Classification, &l.Product.Brand,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(returns) == 0 || returns[len(returns)-1].ReturnID != ret.ReturnID {
+			ret.Lines = []models.ReturnLine{}
+			returns = append(returns, ret)
+		}
+		last := &returns[len(returns)-1]
+		last.Lines = append(last.Lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// InsertReturn registers a return over the lines of a sale at an open register session, puts the units back into
+// the stock of the branch that sold them and records the refund.
+//
+// The refund first settles what is still owed on the sale, a sale left with nothing owed is finalized; the rest
+// is handed out in cash from the drawer of the session. Returned units flagged as damaged go to the damaged stock
+// of the product instead of the sellable one.
+func (r *Repository) InsertReturn(ret models.ReturnDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cashier, err := lockOpenSession(ctx, tx, ret.SessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sale lines are locked so two returns over the same line can not exceed the sold quantity.
+	// Refunds carry the same tax ratio the sale was charged with
+	query := `
+		SELECT
+			d.id_detalle,
+			d.id_producto,
+			d.cantidad - COALESCE((SELECT SUM(dd.cantidad) FROM detalle_devolucion dd WHERE dd.id_detalle = d.id_detalle), 0),
+			(d.precio_unitario - d.descuento / d.cantidad) * COALESCE(v.total / NULLIF(v.subtotal, 0), 1),
+			v.id_sucursal
+		FROM detalle_venta d
+		INNER JOIN venta v
+			ON v.id_venta = d.id_venta
+		WHERE d.id_venta = $1
+		ORDER BY d.id_detalle
+		FOR UPDATE OF d;
+	`
+
+	rows, err := tx.QueryContext(ctx, query, ret.SaleID)
+	if err != nil {
+		return 0, err
+	}
+
+	type saleLine struct {
+		productID  int
+		returnable int
+		unitRefund float32
+	}
+	saleLines := make(map[int]saleLine)
+	var branchID int
+	for rows.Next() {
+		var lineID int
+		l := saleLine{}
+		err := rows.Scan(&lineID, &l.productID, &l.returnable, &l.unitRefund, &branchID)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		saleLines[lineID] = l
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(saleLines) == 0 {
+		return 0, repository.ErrSaleNotFound
+	}
+
+	var total float32
+	refunds := make([]float32, len(ret.Lines))
+	for i, line := range ret.Lines {
+		saleLine, ok := saleLines[line.LineID]
+		if !ok {
+			return 0, repository.ErrSaleNotFound
+		}
+
+		if line.Amount > saleLine.returnable {
+			return 0, repository.ErrReturnExceedsSale
+		}
+		saleLine.returnable -= line.Amount
+		saleLines[line.LineID] = saleLine
+
+		refunds[i] = pricing.RoundCents(saleLine.unitRefund * float32(line.Amount))
+		total += refunds[i]
+	}
+
+	total = pricing.RoundCents(total)
+
+	// Locking the sale waits for payments still being applied over it
+	var owed float32
+	query = `
+		SELECT
+			v.total
+			- COALESCE((SELECT SUM(pv.monto - pv.cambio) FROM pago_venta pv WHERE pv.id_venta = v.id_venta), 0)
+			- ` + settledRefundsQuery + `
+		FROM venta v
+		WHERE v.id_venta = $1
+		FOR UPDATE;
+	`
+	err = tx.QueryRowContext(ctx, query, ret.SaleID).Scan(&owed)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := pricing.RoundCents(owed)
+	if settled < 0 {
+		settled = 0
+	}
+	if settled > total {
+		settled = total
+	}
+
+	if settled > 0 && settled >= pricing.RoundCents(owed) {
+		query = `UPDATE venta SET estado = $1 WHERE id_venta = $2 AND estado = $3;`
+		_, err = tx.ExecContext(ctx, query, models.SaleStatusFinalized, ret.SaleID, models.SaleStatusPending)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query = `
+		INSERT INTO devolucion (id_venta, id_sesion, fecha, motivo, reembolso, efectivo)
+		VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4, $5) RETURNING id_devolucion;
+	`
+
+	var returnID int
+	err = tx.QueryRowContext(ctx, query,
+		ret.SaleID,
+		ret.SessionID,
+		ret.Reason,
+		total,
+		pricing.RoundCents(total-settled),
+	).Scan(&returnID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordStockMovement(ctx, tx, models.MovementReturn, returnReference(returnID), cashier)
+	if err != nil {
+		return 0, err
+	}
+
+	// Damaged units stay out of the cost layers, their cost remains part of the cost of goods sold
+	for i, line := range ret.Lines {
+		var cost float32
+		if !line.Damaged {
+			cost, err = restoreLineCost(ctx, tx, line.LineID, line.Amount, returnReference(returnID))
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		query = `
+			INSERT INTO detalle_devolucion (id_devolucion, id_detalle, cantidad, danado, reembolso, costo)
+			VALUES ($1, $2, $3, $4, $5, $6);
+		`
+		_, err = tx.ExecContext(ctx, query, returnID, line.LineID, line.Amount, line.Damaged, refunds[i], cost)
+		if err != nil {
+			return 0, err
+		}
+
+		if !line.Damaged {
+			err = addBranchStock(ctx, tx, branchID, saleLines[line.LineID].productID, line.Amount)
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		query = `UPDATE producto SET stock_danado = stock_danado + $1 WHERE id_producto = $2;`
+		_, err = tx.ExecContext(ctx, query, line.Amount, saleLines[line.LineID].productID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return returnID, nil
+}
+
+// GetSalesReport sums the sales made between two dates and nets out the refunds given in the same period
+func (r *Repository) GetSalesReport(from, to time.Time) (models.SalesReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	report := models.SalesReport{From: from, To: to}
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM venta WHERE fecha BETWEEN $1 AND $2),
+			(SELECT COALESCE(SUM(total), 0) FROM venta WHERE fecha BETWEEN $1 AND $2),
+			(SELECT COALESCE(SUM(reembolso), 0) FROM devolucion WHERE fecha::date BETWEEN $1 AND $2),
+			(
+				SELECT COALESCE(SUM(d.costo), 0)
+				FROM detalle_venta d
+				INNER JOIN venta v
+					ON v.id_venta = d.id_venta
+				WHERE v.fecha BETWEEN $1 AND $2
+			) - (
+				SELECT COALESCE(SUM(dd.costo), 0)
+				FROM detalle_devolucion dd
+				INNER JOIN devolucion dv
+					ON dv.id_devolucion = dd.id_devolucion
+				WHERE dv.fecha::date BETWEEN $1 AND $2
+			);
+	`
+
+	err := r.db.QueryRowContext(ctx, query, from, to).Scan(&report.Sales, &report.Gross, &report.Refunds, &report.CostOfGoods)
+	if err != nil {
+		return report, err
+	}
+	report.Net = pricing.RoundCents(report.Gross - report.Refunds)
+	report.CostOfGoods = pricing.RoundCents(report.CostOfGoods)
+
+	return report, nil
+}
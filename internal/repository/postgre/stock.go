@@ -0,0 +1,215 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetLowStockProducts fetches the products whose stock is at or below their reorder point, the lowest first.
+//
+// The suggested amount takes the stock back to the maximum level, or to the reorder point when there is none.
+func (r *Repository) GetLowStockProducts() ([]models.LowStockProduct, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+		SELECT
+			id_producto,
+			clasificacion,
+			marca,
+			stock,
+			stock_minimo,
+			stock_maximo,
+			GREATEST(stock_maximo, stock_minimo) - stock
+		FROM producto
+		WHERE stock_minimo > 0 AND stock <= stock_minimo
+		ORDER BY stock - stock_minimo, id_producto;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.LowStockProduct{}
+	for rows.Next() {
+		p := models.LowStockProduct{}
+		err := rows.Scan(&p.ProductID, &p.Classification, &p.Brand, &p.Amount, &p.MinStock, &p.MaxStock, &p.Suggested)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// reserveStock locks every product of the lines and decrements its stock at a branch, fails if any product is
+// short there. It returns the catalog data of every product, read while it was locked, indexed by product id.
+//
+// Products are locked in ascending id order so concurrent sales can not deadlock each other.
+func reserveStock(ctx context.Context, tx *sql.Tx, branchID int, lines []models.SaleLineDTO) (map[int]models.Product, error) {
+	requested := make(map[int]int)
+	for _, line := range lines {
+		requested[line.ProductID] += line.Amount
+	}
+
+	productIDs := make([]int, 0, len(requested))
+	for productID := range requested {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	products := make(map[int]models.Product, len(productIDs))
+	for _, productID := range productIDs {
+		p := models.Product{ProductID: productID}
+		query := `
+			SELECT COALESCE(e.stock, 0), p.precio_publico, p.marca, p.id_categoria
+			FROM producto p
+			LEFT JOIN existencia e
+				ON e.id_producto = p.id_producto AND e.id_sucursal = $2
+			WHERE p.id_producto = $1
+			FOR UPDATE OF p;
+		`
+		err := tx.QueryRowContext(ctx, query, productID, branchID).Scan(&p.Amount, &p.PublicPrice, &p.Brand, &p.Category.CategoryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrProductNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		products[productID] = p
+
+		if p.Amount < requested[productID] {
+			return nil, &repository.InsufficientStockError{
+				ProductID: productID,
+				Requested: requested[productID],
+				Available: p.Amount,
+			}
+		}
+
+		err = addBranchStock(ctx, tx, branchID, productID, -requested[productID])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return products, nil
+}
+
+// releaseStock gives back to the stock of its branch every unit sold on a sale, and their cost to the cost layers
+func releaseStock(ctx context.Context, tx *sql.Tx, saleID int) error {
+	query := `
+		SELECT id_producto
+		FROM producto
+		WHERE id_producto IN (SELECT id_producto FROM detalle_venta WHERE id_venta = $1)
+		ORDER BY id_producto
+		FOR UPDATE;
+	`
+	_, err := tx.ExecContext(ctx, query, saleID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO existencia (id_sucursal, id_producto, stock)
+		SELECT v.id_sucursal, d.id_producto, SUM(d.cantidad)
+		FROM detalle_venta d
+		INNER JOIN venta v
+			ON v.id_venta = d.id_venta
+		WHERE d.id_venta = $1
+		GROUP BY v.id_sucursal, d.id_producto
+		ON CONFLICT (id_sucursal, id_producto) DO UPDATE SET stock = existencia.stock + EXCLUDED.stock;
+	`
+
+	_, err = tx.ExecContext(ctx, query, saleID)
+	if err != nil {
+		return err
+	}
+
+	query = `SELECT id_detalle, cantidad FROM detalle_venta WHERE id_venta = $1 ORDER BY id_detalle;`
+	rows, err := tx.QueryContext(ctx, query, saleID)
+	if err != nil {
+		return err
+	}
+
+	var lineIDs, amounts []int
+	for rows.Next() {
+		var lineID, amount int
+		err := rows.Scan(&lineID, &amount)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		lineIDs = append(lineIDs, lineID)
+		amounts = append(amounts, amount)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, lineID := range lineIDs {
+		_, err = restoreLineCost(ctx, tx, lineID, amounts[i], saleReference(saleID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addBranchStock adds units, or takes them out when negative, to the stock of a product at a branch. The total of
+// the product is kept by a trigger
+func addBranchStock(ctx context.Context, tx *sql.Tx, branchID, productID, amount int) error {
+	if amount == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO existencia (id_sucursal, id_producto, stock)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id_sucursal, id_producto) DO UPDATE SET stock = existencia.stock + EXCLUDED.stock;
+	`
+	_, err := tx.ExecContext(ctx, query, branchID, productID, amount)
+	return err
+}
+
+// catalogProducts reads the catalog data of the products of the lines without locking them, indexed by product id
+func catalogProducts(ctx context.Context, tx *sql.Tx, lines []models.SaleLineDTO) (map[int]models.Product, error) {
+	products := make(map[int]models.Product, len(lines))
+	for _, line := range lines {
+		if _, ok := products[line.ProductID]; ok {
+			continue
+		}
+
+		p := models.Product{ProductID: line.ProductID}
+		query := `
+			SELECT stock, precio_publico, marca, id_categoria
+			FROM producto
+			WHERE id_producto = $1;
+		`
+		err := tx.QueryRowContext(ctx, query, line.ProductID).Scan(&p.Amount, &p.PublicPrice, &p.Brand, &p.Category.CategoryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrProductNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		products[line.ProductID] = p
+	}
+
+	return products, nil
+}
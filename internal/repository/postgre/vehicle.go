@@ -0,0 +1,391 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/repository"
+)
+
+// GetAllVehicles fetches the vehicle catalog ordered by make, model and years
+func (r *Repository) GetAllVehicles() ([]models.Vehicle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	vehicles := []models.Vehicle{}
+	query := `
+		SELECT id_vehiculo, marca, modelo, anio_inicio, anio_fin, motor, version
+		FROM vehiculo
+		ORDER BY marca, modelo, anio_inicio, anio_fin, motor, version;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := models.Vehicle{}
+		err := rows.Scan(&v.VehicleID, &v.Make, &v.Model, &v.YearFrom, &v.YearTo, &v.Engine, &v.Trim)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+// InsertVehicle adds a vehicle to the catalog, returns its id
+func (r *Repository) InsertVehicle(vehicle models.VehicleDTO) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	vehicle = normalizeVehicle(vehicle)
+	vehicleID, err := findVehicle(ctx, tx, vehicle)
+	if err != nil {
+		return 0, err
+	}
+
+	if vehicleID != 0 {
+		return 0, repository.ErrVehicleExists
+	}
+
+	vehicleID, err = insertVehicle(ctx, tx, vehicle)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return vehicleID, nil
+}
+
+// UpdateVehicle rewrites a vehicle of the catalog, the products that fit it keep fitting it
+func (r *Repository) UpdateVehicle(vehicleID int, vehicle models.VehicleDTO) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	vehicle = normalizeVehicle(vehicle)
+	existingID, err := findVehicle(ctx, tx, vehicle)
+	if err != nil {
+		return 0, err
+	}
+
+	if existingID != 0 && existingID != vehicleID {
+		return 0, repository.ErrVehicleExists
+	}
+
+	query := `
+		UPDATE vehiculo
+		SET marca = $1, modelo = $2, anio_inicio = $3, anio_fin = $4, motor = $5, version = $6
+		WHERE id_vehiculo = $7;
+	`
+	result, err := tx.ExecContext(ctx, query,
+		vehicle.Make,
+		vehicle.Model,
+		vehicle.YearFrom,
+		vehicle.YearTo,
+		vehicle.Engine,
+		vehicle.Trim,
+		vehicleID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, tx.Commit()
+}
+
+// DeleteVehicle removes a vehicle from the catalog together with its fitments
+func (r *Repository) DeleteVehicle(vehicleID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM vehiculo WHERE id_vehiculo = $1;`, vehicleID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetProductFitment fetches the vehicles a product fits
+func (r *Repository) GetProductFitment(productID int) ([]models.Fitment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	fitments := []models.Fitment{}
+	query := `
+		SELECT a.notas, v.id_vehiculo, v.marca, v.modelo, v.anio_inicio, v.anio_fin, v.motor, v.version
+		FROM aplicacion a
+		INNER JOIN vehiculo v
+			ON v.id_vehiculo = a.id_vehiculo
+		WHERE a.id_producto = $1
+		ORDER BY v.marca, v.modelo, v.anio_inicio, v.anio_fin, v.motor, v.version;
+	`
+
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := models.Fitment{}
+		v := &f.Vehicle
+		err := rows.Scan(&f.Notes, &v.VehicleID, &v.Make, &v.Model, &v.YearFrom, &v.YearTo, &v.Engine, &v.Trim)
+		if err != nil {
+			return nil, err
+		}
+		fitments = append(fitments, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fitments, tx.Commit()
+}
+
+// SetProductFitment records that a product fits a vehicle of the catalog or updates the notes of the fitment
+func (r *Repository) SetProductFitment(productID int, fitment models.FitmentDTO) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = checkProductExists(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+
+	err = checkVehicleExists(ctx, tx, fitment.VehicleID)
+	if err != nil {
+		return err
+	}
+
+	err = setFitment(ctx, tx, productID, fitment.VehicleID, fitment.Notes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteProductFitment records that a product no longer fits a vehicle
+func (r *Repository) DeleteProductFitment(productID, vehicleID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `DELETE FROM aplicacion WHERE id_producto = $1 AND id_vehiculo = $2;`
+	result, err := r.db.ExecContext(ctx, query, productID, vehicleID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ImportFitment records the fitments of a bulk import, adding to the catalog the vehicles it does not have yet.
+//
+// Rows whose code does not match exactly one product are skipped and reported, the rest are imported together.
+func (r *Repository) ImportFitment(rows []models.FitmentRowDTO) (models.FitmentImport, error) {
+	// Imports come from supplier catalogs with thousands of rows, they get more time than a single operation
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	result := models.FitmentImport{Errors: []models.FitmentImportError{}}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id_producto FROM producto WHERE sku = $1
+		UNION SELECT id_producto FROM producto WHERE numero_parte = $1
+		UNION SELECT id_producto FROM producto WHERE numero_oem = $1
+		UNION SELECT id_producto FROM codigo_barras WHERE codigo = $1
+		LIMIT 2;
+	`
+	for _, row := range rows {
+		var productIDs []int
+		matches, err := tx.QueryContext(ctx, query, normalizeCode(row.Code))
+		if err != nil {
+			return result, err
+		}
+		for matches.Next() {
+			var productID int
+			err := matches.Scan(&productID)
+			if err != nil {
+				matches.Close()
+				return result, err
+			}
+			productIDs = append(productIDs, productID)
+		}
+		matches.Close()
+
+		if err := matches.Err(); err != nil {
+			return result, err
+		}
+
+		if len(productIDs) != 1 {
+			message := "Producto no encontrado: " + row.Code
+			if len(productIDs) > 1 {
+				message = "El código corresponde a más de un producto: " + row.Code
+			}
+			result.Errors = append(result.Errors, models.FitmentImportError{Line: row.Line, Message: message})
+			continue
+		}
+
+		vehicle := normalizeVehicle(row.Vehicle)
+		vehicleID, err := findVehicle(ctx, tx, vehicle)
+		if err != nil {
+			return result, err
+		}
+
+		if vehicleID == 0 {
+			vehicleID, err = insertVehicle(ctx, tx, vehicle)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		err = setFitment(ctx, tx, productIDs[0], vehicleID, row.Notes)
+		if err != nil {
+			return result, err
+		}
+		result.Imported++
+	}
+
+	return result, tx.Commit()
+}
+
+// setFitment links a product to a vehicle, updating the notes when they were already linked
+func setFitment(ctx context.Context, tx *sql.Tx, productID, vehicleID int, notes string) error {
+	query := `
+		INSERT INTO aplicacion (id_producto, id_vehiculo, notas)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id_producto, id_vehiculo) DO UPDATE SET notas = EXCLUDED.notas;
+	`
+	_, err := tx.ExecContext(ctx, query, productID, vehicleID, strings.TrimSpace(notes))
+	return err
+}
+
+// findVehicle returns the id of the vehicle of the catalog with the same attributes, ignoring case, or 0 when
+// there is none
+func findVehicle(ctx context.Context, tx *sql.Tx, vehicle models.VehicleDTO) (int, error) {
+	var vehicleID int
+	query := `
+		SELECT id_vehiculo
+		FROM vehiculo
+		WHERE LOWER(marca) = LOWER($1) AND LOWER(modelo) = LOWER($2) AND anio_inicio = $3 AND anio_fin = $4
+			AND LOWER(motor) = LOWER($5) AND LOWER(version) = LOWER($6);
+	`
+	err := tx.QueryRowContext(ctx, query,
+		vehicle.Make,
+		vehicle.Model,
+		vehicle.YearFrom,
+		vehicle.YearTo,
+		vehicle.Engine,
+		vehicle.Trim,
+	).Scan(&vehicleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return vehicleID, err
+}
+
+// insertVehicle inserts a vehicle into the catalog, returns its id
+func insertVehicle(ctx context.Context, tx *sql.Tx, vehicle models.VehicleDTO) (int, error) {
+	var vehicleID int
+	query := `
+		INSERT INTO vehiculo (marca, modelo, anio_inicio, anio_fin, motor, version)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id_vehiculo;
+	`
+	err := tx.QueryRowContext(ctx, query,
+		vehicle.Make,
+		vehicle.Model,
+		vehicle.YearFrom,
+		vehicle.YearTo,
+		vehicle.Engine,
+		vehicle.Trim,
+	).Scan(&vehicleID)
+
+	return vehicleID, err
+}
+
+// checkVehicleExists fails with repository.ErrVehicleNotFound when a vehicle is not in the catalog
+func checkVehicleExists(ctx context.Context, tx *sql.Tx, vehicleID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM vehiculo WHERE id_vehiculo = $1);`
+	err := tx.QueryRowContext(ctx, query, vehicleID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return repository.ErrVehicleNotFound
+	}
+
+	return nil
+}
+
+// normalizeVehicle trims the attributes of a vehicle, a vehicle without last year is sold a single year
+func normalizeVehicle(vehicle models.VehicleDTO) models.VehicleDTO {
+	vehicle.Make = strings.TrimSpace(vehicle.Make)
+	vehicle.Model = strings.TrimSpace(vehicle.Model)
+	vehicle.Engine = strings.TrimSpace(vehicle.Engine)
+	vehicle.Trim = strings.TrimSpace(vehicle.Trim)
+	if vehicle.YearTo == 0 {
+		vehicle.YearTo = vehicle.YearFrom
+	}
+
+	return vehicle
+}
@@ -1,14 +1,43 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
 )
 
 type DatabaseRepo interface {
 	InsertProduct(product models.ProductDTO) error
-	GetAllProducts() ([]models.Product, error)
+	GetAllProducts(filter models.ProductFilter) ([]models.Product, error)
 	UpdateProduct(productID int, product models.ProductDTO) (int64, error)
 	DeleteProduct(productID int) (int64, error)
+	GetLowStockProducts() ([]models.LowStockProduct, error)
+	GetProductProviders(productID int) ([]models.ProductProvider, error)
+	SetProductProvider(productID int, link models.ProductProviderDTO) error
+	DeleteProductProvider(productID, providerID int) (int64, error)
+	LookupProducts(code string) ([]models.Product, error)
+	SetProductCodes(productID int, codes models.ProductCodesDTO) error
+	GetKardex(productID int, from, to *time.Time) (models.Kardex, error)
+	UpdatePrices(update models.PriceUpdateDTO) (models.PriceUpdate, error)
+	GetProductPriceHistory(productID int) ([]models.PriceChange, error)
+	GetPriceSuggestion(categoryID int, brand string, providerID int, cost float32) (models.PriceSuggestion, error)
+	GetProductCrossReferences(productID int) ([]models.CrossReference, error)
+	InsertCrossReference(productID int, reference models.CrossReferenceDTO) (int, error)
+	DeleteCrossReference(productID, crossReferenceID int) (int64, error)
+	GetProductFitment(productID int) ([]models.Fitment, error)
+	SetProductFitment(productID int, fitment models.FitmentDTO) error
+	DeleteProductFitment(productID, vehicleID int) (int64, error)
+	ImportFitment(rows []models.FitmentRowDTO) (models.FitmentImport, error)
+
+	GetAllMarkupRules() ([]models.MarkupRule, error)
+	InsertMarkupRule(rule models.MarkupRuleDTO) (int, error)
+	UpdateMarkupRule(ruleID int, rule models.MarkupRuleDTO) (int64, error)
+	DeleteMarkupRule(ruleID int) (int64, error)
+
+	GetAllVehicles() ([]models.Vehicle, error)
+	InsertVehicle(vehicle models.VehicleDTO) (int, error)
+	UpdateVehicle(vehicleID int, vehicle models.VehicleDTO) (int64, error)
+	DeleteVehicle(vehicleID int) (int64, error)
 
 	GetAllProviders() ([]models.Provider, error)
 	InsertProvider(provider models.ProviderDTO) error
@@ -16,20 +45,83 @@ type DatabaseRepo interface {
 	DeleteProvider(providerID int) (int64, error)
 
 	GetAllSales() ([]models.Sale, error)
-	InsertSale(sale models.SaleDTO) error
+	InsertSale(sale models.SaleDTO) (models.SaleReceipt, error)
+	AddSalePayments(saleID int, payment models.SalePaymentDTO) (models.SaleReceipt, error)
 	UpdateSale(saleId int, sale models.SaleDTO) (int64, error)
 	DeleteSale(saleId int) (int64, error)
+	GetSalesReport(from, to time.Time) (models.SalesReport, error)
+
+	GetAllReturns() ([]models.Return, error)
+	InsertReturn(ret models.ReturnDTO) (int, error)
+
+	GetAllRegisterSessions() ([]models.RegisterSession, error)
+	GetRegisterSession(sessionID int) (models.RegisterSession, error)
+	OpenRegisterSession(session models.OpenRegisterDTO) (int, error)
+	InsertCashMovement(sessionID int, movement models.CashMovementDTO) error
+	CloseRegisterSession(sessionID int, closing models.CloseRegisterDTO) (models.RegisterSession, error)
+
+	GetAllQuotes() ([]models.Quote, error)
+	GetQuote(quoteID int) (models.Quote, error)
+	InsertQuote(quote models.QuoteDTO) (int, error)
+	CancelQuote(quoteID int) error
+	ConvertQuote(quoteID int, conversion models.ConvertQuoteDTO) (models.SaleReceipt, error)
+
+	GetAllPromotions() ([]models.Promotion, error)
+	InsertPromotion(promotion models.PromotionDTO) error
+	UpdatePromotion(promotionID int, promotion models.PromotionDTO) (int64, error)
+	DeletePromotion(promotionID int) (int64, error)
 
 	GetAllDeliveries() ([]models.Delivery, error)
 	InsertDelivery(delivery models.DeliveryDTO) (int64, error)
 	DeleteDelivery(productID, providerID int) (int64, error)
 
+	GetAllPurchaseOrders() ([]models.PurchaseOrder, error)
+	GetPurchaseOrder(orderID int) (models.PurchaseOrder, error)
+	InsertPurchaseOrder(order models.PurchaseOrderDTO) (int, error)
+	UpdatePurchaseOrder(orderID int, order models.PurchaseOrderDTO) error
+	SendPurchaseOrder(orderID int) error
+	CancelPurchaseOrder(orderID int) error
+	ReceivePurchaseOrder(orderID int, receipt models.GoodsReceiptDTO) (int, error)
+	GetPurchaseOrderReceipts(orderID int) ([]models.GoodsReceipt, error)
+
+	GetAllInventoryCounts() ([]models.InventoryCount, error)
+	GetInventoryCount(countID int) (models.InventoryCount, error)
+	InsertInventoryCount(count models.InventoryCountDTO) (int, error)
+	RecordCountEntries(countID int, entries models.CountEntriesDTO) error
+	PostInventoryCount(countID int, approval models.PostCountDTO) (int, error)
+	CancelInventoryCount(countID int) error
+	GetInventoryValuation(date time.Time, method string) (models.InventoryValuation, error)
+
+	GetAllBranches() ([]models.Branch, error)
+	InsertBranch(branch models.BranchDTO) (int, error)
+	UpdateBranch(branchID int, branch models.BranchDTO) (int64, error)
+
+	GetAllTransfers() ([]models.Transfer, error)
+	InsertTransfer(transfer models.TransferDTO) (int, error)
+	ReceiveTransfer(transferID int) error
+	CancelTransfer(transferID int) error
+
 	GetAllClients() ([]models.Client, error)
 	InsertClient(client models.ClientDTO) error
 	UpdateClient(cliendId int, client models.ClientDTO) (int64, error)
 	DeleteClient(clientId int) (int64, error)
+	GetClientStatement(clientID int) (models.ClientStatement, error)
+	ApplyClientPayment(clientID int, payment models.ClientPaymentDTO) ([]models.SaleReceipt, error)
+	UpdateClientTaxData(clientID int, taxData models.TaxDataDTO) (int64, error)
+
+	GetAllInvoices() ([]models.Invoice, error)
+	GetInvoice(invoiceID int) (models.Invoice, error)
+	ReserveInvoice(saleID int, series string, taxRate float32) (models.Invoice, error)
+	StampInvoice(invoice models.Invoice) error
 
-	GetAllBrands() ([]string, error)
+	GetAllBrands() ([]models.Brand, error)
+	ResolveBrand(brandID int, name string) (models.Brand, error)
+	InsertBrand(brand models.BrandDTO) (int, error)
+	UpdateBrand(brandID int, brand models.BrandDTO) (int64, error)
+	DeleteBrand(brandID, mergeInto int) (int64, error)
 
 	GetAllCategories() ([]models.Category, error)
+	InsertCategory(category models.CategoryDTO) (int, error)
+	UpdateCategory(categoryID int, category models.CategoryDTO) (int64, error)
+	DeleteCategory(categoryID, reassignTo int) (int64, error)
 }
@@ -0,0 +1,60 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidBranch checks if a incoming branch has a name and both its name and address fit in database
+func IsValidBranch(branch models.BranchDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(branch.Name) == "" {
+		resp := helpers.Response{Message: "La sucursal debe tener un nombre", Error: true}
+		return false, resp
+	}
+
+	if len(branch.Name) > 100 {
+		resp := helpers.Response{Message: "El nombre no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	if len(branch.Address) > 200 {
+		resp := helpers.Response{Message: "La dirección no puede exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidTransfer checks if a incoming transfer goes between two different branches and has valid lines
+func IsValidTransfer(transfer models.TransferDTO) (bool, helpers.Response) {
+	if transfer.OriginID <= 0 || transfer.DestinationID <= 0 {
+		resp := helpers.Response{Message: "El traspaso debe tener una sucursal de origen y una de destino", Error: true}
+		return false, resp
+	}
+
+	if transfer.OriginID == transfer.DestinationID {
+		resp := helpers.Response{Message: "La sucursal de origen y la de destino deben ser distintas", Error: true}
+		return false, resp
+	}
+
+	if len(transfer.Lines) == 0 {
+		resp := helpers.Response{Message: "El traspaso debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range transfer.Lines {
+		if line.ProductID <= 0 || line.Amount <= 0 {
+			resp := helpers.Response{Message: "Producto o cantidad no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	if len(transfer.Notes) > 200 {
+		resp := helpers.Response{Message: "Las notas no pueden exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
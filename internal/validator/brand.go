@@ -0,0 +1,30 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidBrand checks if a incoming brand has a name and aliases that fit in database
+func IsValidBrand(brand models.BrandDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(brand.Name) == "" {
+		resp := helpers.Response{Message: "La marca debe tener un nombre", Error: true}
+		return false, resp
+	}
+
+	if len(brand.Name) > 100 {
+		resp := helpers.Response{Message: "El nombre no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	for _, alias := range brand.Aliases {
+		if strings.TrimSpace(alias) == "" || len(alias) > 100 {
+			resp := helpers.Response{Message: "Los alias no pueden estar vacíos ni exceder 100 caracteres", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidCategory checks if a incoming category has a name that fits in database and a valid parent
+func IsValidCategory(category models.CategoryDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(category.Name) == "" {
+		resp := helpers.Response{Message: "La categoría debe tener un nombre", Error: true}
+		return false, resp
+	}
+
+	if len(category.Name) > 50 {
+		resp := helpers.Response{Message: "El nombre no puede exceder 50 caracteres", Error: true}
+		return false, resp
+	}
+
+	if category.ParentID < 0 {
+		resp := helpers.Response{Message: "Categoría padre no válida", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"regexp"
+
 	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
 	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
 	"github.com/asaskevich/govalidator"
@@ -13,5 +15,42 @@ func IsValidClient(client models.ClientDTO) (bool, helpers.Response) {
 		return false, resp
 	}
 
+	if client.CreditLimit < 0 {
+		resp := helpers.Response{Message: "Límite de crédito no válido", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+var (
+	rfcPattern     = regexp.MustCompile(`^[A-Z&Ñ]{3,4}[0-9]{6}[A-Z0-9]{3}$`)
+	zipCodePattern = regexp.MustCompile(`^[0-9]{5}$`)
+	regimePattern  = regexp.MustCompile(`^[0-9]{3}$`)
+	cfdiUsePattern = regexp.MustCompile(`^([GDI][0-9]{2}|S01|CP01|CN01)$`)
+)
+
+// IsValidTaxData checks if the tax data of a client follows the formats of the SAT
+func IsValidTaxData(taxData models.TaxDataDTO) (bool, helpers.Response) {
+	if !rfcPattern.MatchString(taxData.RFC) {
+		resp := helpers.Response{Message: "RFC no válido", Error: true}
+		return false, resp
+	}
+
+	if !zipCodePattern.MatchString(taxData.ZipCode) {
+		resp := helpers.Response{Message: "Código postal fiscal no válido", Error: true}
+		return false, resp
+	}
+
+	if !regimePattern.MatchString(taxData.TaxRegime) {
+		resp := helpers.Response{Message: "Régimen fiscal no válido", Error: true}
+		return false, resp
+	}
+
+	if !cfdiUsePattern.MatchString(taxData.CfdiUse) {
+		resp := helpers.Response{Message: "Uso de CFDI no válido", Error: true}
+		return false, resp
+	}
+
 	return true, helpers.Response{}
 }
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidCrossReference checks if a incoming equivalence of productID has a known kind, is either another product
+// or a part number of another brand and fits in database
+func IsValidCrossReference(productID int, reference models.CrossReferenceDTO) (bool, helpers.Response) {
+	switch reference.Kind {
+	case models.EquivalenceExact, models.EquivalenceAlternative, models.EquivalenceSuperseded:
+	default:
+		resp := helpers.Response{Message: "Tipo de equivalencia no válido", Error: true}
+		return false, resp
+	}
+
+	hasProduct := reference.EquivalentID > 0
+	hasPartNumber := strings.TrimSpace(reference.PartNumber) != ""
+	if reference.EquivalentID < 0 || hasProduct == hasPartNumber {
+		resp := helpers.Response{Message: "La equivalencia debe ser un producto o un número de parte externo", Error: true}
+		return false, resp
+	}
+
+	if reference.EquivalentID == productID {
+		resp := helpers.Response{Message: "Un producto no puede ser equivalente de sí mismo", Error: true}
+		return false, resp
+	}
+
+	if len(strings.TrimSpace(reference.PartNumber)) > 40 {
+		resp := helpers.Response{Message: "El número de parte no puede exceder 40 caracteres", Error: true}
+		return false, resp
+	}
+
+	if len(strings.TrimSpace(reference.PartBrand)) > 50 {
+		resp := helpers.Response{Message: "La marca no puede exceder 50 caracteres", Error: true}
+		return false, resp
+	}
+
+	if len(reference.Notes) > 200 {
+		resp := helpers.Response{Message: "Las notas no pueden exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidInventoryCount checks if a incoming count has a known kind and, when it is a cycle count, products to count
+func IsValidInventoryCount(count models.InventoryCountDTO) (bool, helpers.Response) {
+	if len(count.Description) > 100 {
+		resp := helpers.Response{Message: "La descripción no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	if count.Kind != models.CountFull && count.Kind != models.CountCycle {
+		resp := helpers.Response{Message: "Tipo de conteo no válido", Error: true}
+		return false, resp
+	}
+
+	if count.Kind == models.CountCycle && count.CategoryID <= 0 && len(count.ProductIDs) == 0 {
+		resp := helpers.Response{Message: "El conteo cíclico debe tener una categoría o al menos un producto", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidCountEntries checks if a incoming batch of counted units has products and no negative quantities
+func IsValidCountEntries(entries models.CountEntriesDTO) (bool, helpers.Response) {
+	if len(entries.Lines) == 0 {
+		resp := helpers.Response{Message: "El conteo debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, entry := range entries.Lines {
+		if entry.ProductID <= 0 || entry.Counted < 0 {
+			resp := helpers.Response{Message: "Producto o cantidad no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidCountApproval checks if the reason given for the adjustments of a count fits in database and the approval
+// names the products to adjust or explicitly approves all of them
+func IsValidCountApproval(approval models.PostCountDTO) (bool, helpers.Response) {
+	if len(approval.Reason) > 100 {
+		resp := helpers.Response{Message: "El motivo no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	if approval.ApproveAll == (len(approval.ProductIDs) > 0) {
+		resp := helpers.Response{Message: "Indica los productos a ajustar o aprueba todos explícitamente", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidCostingMethod checks if the inventory can be valued with a costing method, empty means the configured one
+func IsValidCostingMethod(method string) (bool, helpers.Response) {
+	if method != "" && method != models.CostingWeightedAverage && method != models.CostingFIFO {
+		resp := helpers.Response{Message: "Método de costeo no válido", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidMarkupRule checks if a incoming markup rule is scoped by exactly one of category, brand or provider and
+// its markup and minimum margin are coherent percents
+func IsValidMarkupRule(rule models.MarkupRuleDTO) (bool, helpers.Response) {
+	scopes := 0
+	if rule.CategoryID > 0 {
+		scopes++
+	}
+	if strings.TrimSpace(rule.Brand) != "" {
+		scopes++
+	}
+	if rule.ProviderID > 0 {
+		scopes++
+	}
+
+	if scopes != 1 || rule.CategoryID < 0 || rule.ProviderID < 0 {
+		resp := helpers.Response{Message: "La regla debe ser por categoría, por marca o por proveedor", Error: true}
+		return false, resp
+	}
+
+	if len(rule.Brand) > 100 {
+		resp := helpers.Response{Message: "La marca no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	if rule.Markup < 0 || rule.Markup > 9999 {
+		resp := helpers.Response{Message: "Porcentaje de margen no válido", Error: true}
+		return false, resp
+	}
+
+	if rule.MinMargin < 0 || rule.MinMargin >= 100 {
+		resp := helpers.Response{Message: "El margen mínimo debe ser de 0 a menos de 100%", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
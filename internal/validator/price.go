@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidPriceUpdate checks if a incoming bulk price change picks products by at least one filter and moves a known
+// price by a known mode and rounding
+func IsValidPriceUpdate(update models.PriceUpdateDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(update.Brand) == "" && update.CategoryID <= 0 && update.ProviderID <= 0 {
+		resp := helpers.Response{Message: "Se debe filtrar por marca, categoría o proveedor", Error: true}
+		return false, resp
+	}
+
+	if update.Target != models.PricePublic && update.Target != models.PriceProvider {
+		resp := helpers.Response{Message: "Precio a actualizar no válido", Error: true}
+		return false, resp
+	}
+
+	if update.Mode != models.RepriceByPercent && update.Mode != models.RepriceByAmount {
+		resp := helpers.Response{Message: "Tipo de cambio no válido", Error: true}
+		return false, resp
+	}
+
+	if update.Value == 0 || (update.Mode == models.RepriceByPercent && update.Value <= -100) {
+		resp := helpers.Response{Message: "El cambio de precio no es válido", Error: true}
+		return false, resp
+	}
+
+	switch update.Rounding {
+	case "", models.RoundToCents, models.RoundToPeso, models.RoundToNinetyNine:
+	default:
+		resp := helpers.Response{Message: "Regla de redondeo no válida", Error: true}
+		return false, resp
+	}
+
+	if len(update.Reason) > 100 {
+		resp := helpers.Response{Message: "El motivo no puede exceder 100 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidProduct checks if a incoming product has a classification and a brand, by id or by name, and its stock
+// levels are coherent, a maximum of 0 means no maximum
+func IsValidProduct(product models.ProductDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(product.Classification) == "" || (strings.TrimSpace(product.Brand) == "" && product.BrandID <= 0) {
+		resp := helpers.Response{Message: "Todos los campos son obligatorios", Error: true}
+		return false, resp
+	}
+
+	if product.MinStock < 0 || product.MaxStock < 0 {
+		resp := helpers.Response{Message: "Los niveles de stock no pueden ser negativos", Error: true}
+		return false, resp
+	}
+
+	if product.MaxStock > 0 && product.MaxStock < product.MinStock {
+		resp := helpers.Response{Message: "El stock máximo no puede ser menor al mínimo", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidProductProvider checks if the terms a provider sells a product with are coherent
+func IsValidProductProvider(link models.ProductProviderDTO) (bool, helpers.Response) {
+	if link.ProviderID <= 0 {
+		resp := helpers.Response{Message: "Proveedor no válido", Error: true}
+		return false, resp
+	}
+
+	if link.Cost < 0 || link.LeadTime < 0 {
+		resp := helpers.Response{Message: "El costo y el tiempo de entrega no pueden ser negativos", Error: true}
+		return false, resp
+	}
+
+	if len(link.SKU) > 50 {
+		resp := helpers.Response{Message: "El SKU del proveedor no puede exceder 50 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidProductCodes checks if the codes of a product fit in database and every barcode is a valid EAN or UPC
+func IsValidProductCodes(codes models.ProductCodesDTO) (bool, helpers.Response) {
+	for _, code := range []string{codes.SKU, codes.PartNumber, codes.OEMNumber} {
+		if len(strings.TrimSpace(code)) > 40 {
+			resp := helpers.Response{Message: "El SKU y los números de parte no pueden exceder 40 caracteres", Error: true}
+			return false, resp
+		}
+	}
+
+	for _, barcode := range codes.Barcodes {
+		if !isValidBarcode(strings.TrimSpace(barcode)) {
+			resp := helpers.Response{Message: "Código de barras no válido: " + barcode, Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
+
+// isValidBarcode checks if a barcode is an EAN-8, UPC-A, EAN-13 or GTIN-14 with a correct check digit
+func isValidBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	// From the right, digits are weighted 3 and 1 alternately starting with the one before the check digit
+	sum := 0
+	for i := len(barcode) - 1; i >= 0; i-- {
+		digit := int(barcode[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+
+		if i == len(barcode)-1 {
+			continue
+		}
+
+		if (len(barcode)-1-i)%2 == 1 {
+			sum += digit * 3
+		} else {
+			sum += digit
+		}
+	}
+
+	check := int(barcode[len(barcode)-1] - '0')
+	return (10-sum%10)%10 == check
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidPromotion checks if a incoming promotion has a known kind with coherent values and a valid period
+func IsValidPromotion(promotion models.PromotionDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(promotion.Name) == "" {
+		resp := helpers.Response{Message: "El nombre de la promoción es obligatorio", Error: true}
+		return false, resp
+	}
+
+	switch promotion.Kind {
+	case models.PromotionPercent:
+		if promotion.Percent <= 0 || promotion.Percent > 100 {
+			resp := helpers.Response{Message: "Porcentaje de descuento no válido", Error: true}
+			return false, resp
+		}
+	case models.PromotionBuyXGetY:
+		if promotion.Buy <= 0 || promotion.Free <= 0 {
+			resp := helpers.Response{Message: "Cantidades de la promoción no válidas", Error: true}
+			return false, resp
+		}
+	default:
+		resp := helpers.Response{Message: "Tipo de promoción no válido", Error: true}
+		return false, resp
+	}
+
+	startsAt, err := time.Parse("2006-01-02", promotion.StartsAt)
+	if err != nil {
+		resp := helpers.Response{Message: "Fecha de inicio no válida", Error: true}
+		return false, resp
+	}
+
+	endsAt, err := time.Parse("2006-01-02", promotion.EndsAt)
+	if err != nil || endsAt.Before(startsAt) {
+		resp := helpers.Response{Message: "Fecha de fin no válida", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
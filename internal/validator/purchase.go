@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidPurchaseOrder checks if a incoming purchase order has a provider, a valid expected date and valid lines
+func IsValidPurchaseOrder(order models.PurchaseOrderDTO) (bool, helpers.Response) {
+	if order.ProviderID <= 0 {
+		resp := helpers.Response{Message: "La orden de compra debe tener un proveedor", Error: true}
+		return false, resp
+	}
+
+	_, err := time.Parse("2006-01-02", order.ExpectedDate)
+	if err != nil {
+		resp := helpers.Response{Message: "Fecha de entrega esperada no válida", Error: true}
+		return false, resp
+	}
+
+	if len(order.Lines) == 0 {
+		resp := helpers.Response{Message: "La orden de compra debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range order.Lines {
+		if line.ProductID <= 0 || line.Amount <= 0 || line.UnitCost < 0 {
+			resp := helpers.Response{Message: "Producto, cantidad o costo no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidGoodsReceipt checks if a incoming receipt has lines with non negative quantities and costs, every line
+// must report at least one unit
+func IsValidGoodsReceipt(receipt models.GoodsReceiptDTO) (bool, helpers.Response) {
+	if len(receipt.Lines) == 0 {
+		resp := helpers.Response{Message: "La recepción debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range receipt.Lines {
+		if line.LineID <= 0 || line.Received < 0 || line.Damaged < 0 || line.Rejected < 0 || line.UnitCost < 0 {
+			resp := helpers.Response{Message: "Producto, cantidades o costo no válidos", Error: true}
+			return false, resp
+		}
+
+		if line.Received+line.Damaged+line.Rejected == 0 {
+			resp := helpers.Response{Message: "Cada producto de la recepción debe tener al menos una unidad", Error: true}
+			return false, resp
+		}
+	}
+
+	if len(receipt.Notes) > 200 {
+		resp := helpers.Response{Message: "Las notas no pueden exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidQuote checks if a incoming quote has a client, a validity date not in the past and valid lines
+func IsValidQuote(quote models.QuoteDTO) (bool, helpers.Response) {
+	if quote.ClientID <= 0 {
+		resp := helpers.Response{Message: "La cotización debe tener un cliente", Error: true}
+		return false, resp
+	}
+
+	validUntil, err := time.Parse("2006-01-02", quote.ValidUntil)
+	if err != nil || validUntil.Before(time.Now().Truncate(24*time.Hour)) {
+		resp := helpers.Response{Message: "Fecha de vigencia no válida", Error: true}
+		return false, resp
+	}
+
+	if len(quote.Lines) == 0 {
+		resp := helpers.Response{Message: "La cotización debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range quote.Lines {
+		if line.ProductID <= 0 || line.Amount <= 0 {
+			resp := helpers.Response{Message: "Producto o cantidad no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
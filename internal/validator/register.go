@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidCashMovement checks if a incoming cash movement has a known kind and a positive amount
+func IsValidCashMovement(movement models.CashMovementDTO) (bool, helpers.Response) {
+	if movement.Kind != models.CashIn && movement.Kind != models.CashOut {
+		resp := helpers.Response{Message: "Tipo de movimiento no válido", Error: true}
+		return false, resp
+	}
+
+	if movement.Amount <= 0 {
+		resp := helpers.Response{Message: "Monto no válido", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
@@ -0,0 +1,41 @@
+package validator
+
+import (
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+var returnReasons = map[string]bool{
+	models.ReturnReasonDefective: true,
+	models.ReturnReasonWrongPart: true,
+	models.ReturnReasonNotNeeded: true,
+	models.ReturnReasonWarranty:  true,
+	models.ReturnReasonOther:     true,
+}
+
+// IsValidReturn checks if a incoming return has a known reason and positive quantities
+func IsValidReturn(ret models.ReturnDTO) (bool, helpers.Response) {
+	if !returnReasons[ret.Reason] {
+		resp := helpers.Response{Message: "Motivo de devolución no válido", Error: true}
+		return false, resp
+	}
+
+	if ret.SessionID <= 0 {
+		resp := helpers.Response{Message: "La devolución debe registrarse en una caja abierta", Error: true}
+		return false, resp
+	}
+
+	if ret.SaleID <= 0 || len(ret.Lines) == 0 {
+		resp := helpers.Response{Message: "La devolución debe indicar la venta y al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range ret.Lines {
+		if line.LineID <= 0 || line.Amount <= 0 {
+			resp := helpers.Response{Message: "Producto o cantidad no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
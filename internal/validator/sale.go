@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// IsValidSale checks if a incoming sale has at least one line and every line has coherent amounts
+func IsValidSale(sale models.SaleDTO) (bool, helpers.Response) {
+	if sale.SessionID <= 0 {
+		resp := helpers.Response{Message: "La venta debe registrarse en una caja abierta", Error: true}
+		return false, resp
+	}
+
+	if sale.OnCredit && sale.ClientID <= 0 {
+		resp := helpers.Response{Message: "Las ventas a crédito requieren un cliente", Error: true}
+		return false, resp
+	}
+
+	if sale.ManagerOverride != nil && (sale.ManagerOverride.ManagerID <= 0 || sale.ManagerOverride.PIN == "") {
+		resp := helpers.Response{Message: "La autorización de precios requiere gerente y PIN", Error: true}
+		return false, resp
+	}
+
+	if len(sale.Lines) == 0 {
+		resp := helpers.Response{Message: "La venta debe tener al menos un producto", Error: true}
+		return false, resp
+	}
+
+	for _, line := range sale.Lines {
+		if line.ProductID <= 0 || line.Amount <= 0 {
+			resp := helpers.Response{Message: "Producto o cantidad no válidos", Error: true}
+			return false, resp
+		}
+
+		if line.UnitPrice < 0 || line.Discount < 0 || line.Discount > line.UnitPrice*float32(line.Amount) {
+			resp := helpers.Response{Message: "Precio o descuento no válidos", Error: true}
+			return false, resp
+		}
+	}
+
+	return IsValidPayments(sale.Payments)
+}
+
+var paymentMethods = map[string]bool{
+	models.PaymentCash:        true,
+	models.PaymentCard:        true,
+	models.PaymentTransfer:    true,
+	models.PaymentStoreCredit: true,
+}
+
+// IsValidPayments checks if every incoming payment has a known method and a positive amount.
+//
+// Card and transfer payments must carry the reference given by the terminal or the bank.
+func IsValidPayments(payments []models.PaymentDTO) (bool, helpers.Response) {
+	for _, payment := range payments {
+		if !paymentMethods[payment.Method] {
+			resp := helpers.Response{Message: "Método de pago no válido", Error: true}
+			return false, resp
+		}
+
+		if payment.Amount <= 0 {
+			resp := helpers.Response{Message: "Monto de pago no válido", Error: true}
+			return false, resp
+		}
+
+		isBanked := payment.Method == models.PaymentCard || payment.Method == models.PaymentTransfer
+		if isBanked && payment.Reference == "" {
+			resp := helpers.Response{Message: "Los pagos con tarjeta o transferencia requieren referencia", Error: true}
+			return false, resp
+		}
+	}
+
+	return true, helpers.Response{}
+}
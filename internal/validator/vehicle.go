@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DieGopherLT/refaccionaria-backend/internal/helpers"
+	"github.com/DieGopherLT/refaccionaria-backend/internal/models"
+)
+
+// firstVehicleYear oldest model year the catalog accepts
+const firstVehicleYear = 1900
+
+// IsValidVehicle checks if a incoming vehicle has a make and a model, its attributes fit in database and its years
+// make sense, a vehicle can be up to two years ahead of the current one
+func IsValidVehicle(vehicle models.VehicleDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(vehicle.Make) == "" || strings.TrimSpace(vehicle.Model) == "" {
+		resp := helpers.Response{Message: "El vehículo debe tener marca y modelo", Error: true}
+		return false, resp
+	}
+
+	for _, attribute := range []string{vehicle.Make, vehicle.Model, vehicle.Engine, vehicle.Trim} {
+		if len(strings.TrimSpace(attribute)) > 50 {
+			resp := helpers.Response{Message: "La marca, modelo, motor y versión no pueden exceder 50 caracteres", Error: true}
+			return false, resp
+		}
+	}
+
+	lastYear := time.Now().Year() + 2
+	if vehicle.YearFrom < firstVehicleYear || vehicle.YearFrom > lastYear {
+		resp := helpers.Response{Message: "Año inicial no válido", Error: true}
+		return false, resp
+	}
+
+	if vehicle.YearTo != 0 && (vehicle.YearTo < vehicle.YearFrom || vehicle.YearTo > lastYear) {
+		resp := helpers.Response{Message: "Año final no válido", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidFitment checks if a incoming fitment names a vehicle and its notes fit in database
+func IsValidFitment(fitment models.FitmentDTO) (bool, helpers.Response) {
+	if fitment.VehicleID <= 0 {
+		resp := helpers.Response{Message: "Vehículo no válido", Error: true}
+		return false, resp
+	}
+
+	if len(fitment.Notes) > 200 {
+		resp := helpers.Response{Message: "Las notas no pueden exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return true, helpers.Response{}
+}
+
+// IsValidFitmentRow checks if a row of a fitment import has a product code, a valid vehicle and notes that fit in
+// database
+func IsValidFitmentRow(row models.FitmentRowDTO) (bool, helpers.Response) {
+	if strings.TrimSpace(row.Code) == "" {
+		resp := helpers.Response{Message: "La fila debe tener el código del producto", Error: true}
+		return false, resp
+	}
+
+	if len(row.Notes) > 200 {
+		resp := helpers.Response{Message: "Las notas no pueden exceder 200 caracteres", Error: true}
+		return false, resp
+	}
+
+	return IsValidVehicle(row.Vehicle)
+}
@@ -0,0 +1,50 @@
+-- venta becomes the header of a ticket, products sold move to detalle_venta
+
+BEGIN;
+
+CREATE TABLE detalle_venta (
+    id_detalle      SERIAL PRIMARY KEY,
+    id_venta        INTEGER        NOT NULL REFERENCES venta (id_venta) ON DELETE CASCADE,
+    id_producto     INTEGER        NOT NULL REFERENCES producto (id_producto),
+    cantidad        INTEGER        NOT NULL CHECK (cantidad > 0),
+    precio_unitario NUMERIC(12, 2) NOT NULL CHECK (precio_unitario >= 0),
+    descuento       NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (descuento >= 0)
+);
+
+CREATE INDEX detalle_venta_id_venta_idx ON detalle_venta (id_venta);
+
+INSERT INTO detalle_venta (id_venta, id_producto, cantidad, precio_unitario, descuento)
+SELECT id_venta, id_producto, cantidad_vendida, subtotal / cantidad_vendida, 0
+FROM venta
+WHERE cantidad_vendida > 0;
+
+-- The legacy stock trigger reads venta.cantidad_vendida, it has to go before the column does
+DO $$
+DECLARE
+    t RECORD;
+BEGIN
+    FOR t IN SELECT tgname FROM pg_trigger WHERE tgrelid = 'venta'::regclass AND NOT tgisinternal LOOP
+        EXECUTE format('DROP TRIGGER %I ON venta', t.tgname);
+    END LOOP;
+END $$;
+
+ALTER TABLE venta DROP COLUMN id_producto;
+ALTER TABLE venta DROP COLUMN cantidad_vendida;
+
+-- Stock is now discounted per line, created after the copy so history is not discounted twice
+CREATE FUNCTION detalle_venta_stock() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE producto SET stock = stock - NEW.cantidad WHERE id_producto = NEW.id_producto;
+        RETURN NEW;
+    END IF;
+    UPDATE producto SET stock = stock + OLD.cantidad WHERE id_producto = OLD.id_producto;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER detalle_venta_stock
+    AFTER INSERT OR DELETE ON detalle_venta
+    FOR EACH ROW EXECUTE FUNCTION detalle_venta_stock();
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Stock of sold products is now locked, checked and discounted by the application in the sale transaction
+
+BEGIN;
+
+DROP TRIGGER detalle_venta_stock ON detalle_venta;
+DROP FUNCTION detalle_venta_stock();
+
+-- Last line of defense, NOT VALID so rows that already went negative do not block the migration
+ALTER TABLE producto ADD CONSTRAINT producto_stock_check CHECK (stock >= 0) NOT VALID;
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Sale returns, returned units go back to stock or to the damaged bucket of the product
+
+BEGIN;
+
+ALTER TABLE producto ADD COLUMN stock_danado INTEGER NOT NULL DEFAULT 0 CHECK (stock_danado >= 0);
+
+CREATE TABLE devolucion (
+    id_devolucion SERIAL PRIMARY KEY,
+    id_venta      INTEGER        NOT NULL REFERENCES venta (id_venta),
+    fecha         TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    motivo        VARCHAR(20)    NOT NULL,
+    reembolso     NUMERIC(12, 2) NOT NULL CHECK (reembolso >= 0)
+);
+
+CREATE TABLE detalle_devolucion (
+    id_detalle_devolucion SERIAL PRIMARY KEY,
+    id_devolucion         INTEGER        NOT NULL REFERENCES devolucion (id_devolucion) ON DELETE CASCADE,
+    id_detalle            INTEGER        NOT NULL REFERENCES detalle_venta (id_detalle),
+    cantidad              INTEGER        NOT NULL CHECK (cantidad > 0),
+    danado                BOOLEAN        NOT NULL DEFAULT FALSE,
+    reembolso             NUMERIC(12, 2) NOT NULL CHECK (reembolso >= 0)
+);
+
+CREATE INDEX devolucion_id_venta_idx ON devolucion (id_venta);
+CREATE INDEX detalle_devolucion_id_detalle_idx ON detalle_devolucion (id_detalle);
+
+COMMIT;
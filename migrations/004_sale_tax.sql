@@ -0,0 +1,10 @@
+-- Sale totals are computed by the backend, client totals are only stored when a manager authorized them
+
+BEGIN;
+
+ALTER TABLE venta ADD COLUMN iva NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE venta ADD COLUMN precio_autorizado BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE venta SET iva = total - subtotal WHERE total > subtotal;
+
+COMMIT;
@@ -0,0 +1,23 @@
+-- Promotions scoped by product, brand, category or client, sale lines keep the promotion that discounted them
+
+BEGIN;
+
+CREATE TABLE promocion (
+    id_promocion SERIAL PRIMARY KEY,
+    nombre       VARCHAR(100)  NOT NULL,
+    tipo         VARCHAR(20)   NOT NULL CHECK (tipo IN ('percent', 'buy_x_get_y')),
+    porcentaje   NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    compra       INTEGER       NOT NULL DEFAULT 0,
+    regalo       INTEGER       NOT NULL DEFAULT 0,
+    id_producto  INTEGER REFERENCES producto (id_producto) ON DELETE CASCADE,
+    marca        VARCHAR(100),
+    id_categoria INTEGER REFERENCES categoria (id_categoria) ON DELETE CASCADE,
+    id_cliente   INTEGER REFERENCES cliente (id_cliente) ON DELETE CASCADE,
+    fecha_inicio DATE          NOT NULL,
+    fecha_fin    DATE          NOT NULL CHECK (fecha_fin >= fecha_inicio),
+    activa       BOOLEAN       NOT NULL DEFAULT TRUE
+);
+
+ALTER TABLE detalle_venta ADD COLUMN id_promocion INTEGER REFERENCES promocion (id_promocion);
+
+COMMIT;
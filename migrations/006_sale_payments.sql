@@ -0,0 +1,23 @@
+-- Payments of a sale, split tenders are several rows of the same sale
+
+BEGIN;
+
+ALTER TABLE venta ADD COLUMN estado VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (estado IN ('pending', 'finalized'));
+
+-- Sales registered before payments existed were charged at the counter
+UPDATE venta SET estado = 'finalized';
+
+CREATE TABLE pago_venta (
+    id_pago    SERIAL PRIMARY KEY,
+    id_venta   INTEGER        NOT NULL REFERENCES venta (id_venta) ON DELETE CASCADE,
+    fecha      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    metodo     VARCHAR(20)    NOT NULL CHECK (metodo IN ('cash', 'card', 'transfer', 'store_credit')),
+    monto      NUMERIC(12, 2) NOT NULL CHECK (monto > 0),
+    referencia VARCHAR(100)   NOT NULL DEFAULT '',
+    cambio     NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (cambio >= 0 AND cambio <= monto)
+);
+
+CREATE INDEX pago_venta_id_venta_idx ON pago_venta (id_venta);
+
+COMMIT;
@@ -0,0 +1,40 @@
+-- Cash register sessions (corte de caja), every sale and payment is tied to the session it was charged at
+
+BEGIN;
+
+CREATE TABLE sesion_caja (
+    id_sesion     SERIAL PRIMARY KEY,
+    cajero        VARCHAR(100)   NOT NULL,
+    fondo_inicial NUMERIC(12, 2) NOT NULL CHECK (fondo_inicial >= 0),
+    estado        VARCHAR(10)    NOT NULL DEFAULT 'open' CHECK (estado IN ('open', 'closed')),
+    apertura      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cierre        TIMESTAMP
+);
+
+-- A cashier can only have one drawer open at a time
+CREATE UNIQUE INDEX sesion_caja_cajero_abierta_idx ON sesion_caja (cajero) WHERE estado = 'open';
+
+CREATE TABLE movimiento_caja (
+    id_movimiento SERIAL PRIMARY KEY,
+    id_sesion     INTEGER        NOT NULL REFERENCES sesion_caja (id_sesion),
+    tipo          VARCHAR(3)     NOT NULL CHECK (tipo IN ('in', 'out')),
+    monto         NUMERIC(12, 2) NOT NULL CHECK (monto > 0),
+    concepto      VARCHAR(200)   NOT NULL,
+    fecha         TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conteo_caja (
+    id_sesion INTEGER        NOT NULL REFERENCES sesion_caja (id_sesion),
+    metodo    VARCHAR(20)    NOT NULL,
+    esperado  NUMERIC(12, 2) NOT NULL,
+    contado   NUMERIC(12, 2) NOT NULL,
+    PRIMARY KEY (id_sesion, metodo)
+);
+
+-- Sales and payments registered before sessions existed keep a NULL session
+ALTER TABLE venta ADD COLUMN id_sesion INTEGER REFERENCES sesion_caja (id_sesion);
+ALTER TABLE pago_venta ADD COLUMN id_sesion INTEGER REFERENCES sesion_caja (id_sesion);
+
+CREATE INDEX pago_venta_id_sesion_idx ON pago_venta (id_sesion);
+
+COMMIT;
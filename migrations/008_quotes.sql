@@ -0,0 +1,26 @@
+-- Quotes (cotizaciones), once converted they keep a reference to the sale they became
+
+BEGIN;
+
+CREATE TABLE cotizacion (
+    id_cotizacion SERIAL PRIMARY KEY,
+    id_cliente    INTEGER        NOT NULL REFERENCES cliente (id_cliente),
+    fecha         DATE           NOT NULL DEFAULT CURRENT_DATE,
+    vigencia      DATE           NOT NULL,
+    estado        VARCHAR(10)    NOT NULL DEFAULT 'open' CHECK (estado IN ('open', 'converted', 'cancelled')),
+    subtotal      NUMERIC(12, 2) NOT NULL,
+    iva           NUMERIC(12, 2) NOT NULL,
+    total         NUMERIC(12, 2) NOT NULL,
+    id_venta      INTEGER REFERENCES venta (id_venta)
+);
+
+CREATE TABLE detalle_cotizacion (
+    id_detalle_cotizacion SERIAL PRIMARY KEY,
+    id_cotizacion         INTEGER        NOT NULL REFERENCES cotizacion (id_cotizacion) ON DELETE CASCADE,
+    id_producto           INTEGER        NOT NULL REFERENCES producto (id_producto),
+    cantidad              INTEGER        NOT NULL CHECK (cantidad > 0),
+    precio_unitario       NUMERIC(12, 2) NOT NULL,
+    descuento             NUMERIC(12, 2) NOT NULL DEFAULT 0
+);
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Client credit accounts, credit sales stay pending until the client pays them off
+
+BEGIN;
+
+ALTER TABLE cliente
+    ADD COLUMN limite_credito NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (limite_credito >= 0);
+
+ALTER TABLE venta
+    ADD COLUMN a_credito BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX venta_credito_abierto_idx ON venta (id_cliente) WHERE a_credito AND estado = 'pending';
+
+COMMIT;
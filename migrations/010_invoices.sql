@@ -0,0 +1,43 @@
+-- Electronic invoices (CFDI 4.0), folios are consecutive per series and an invoice keeps its folio while it
+-- waits to be stamped
+
+BEGIN;
+
+ALTER TABLE cliente
+    ADD COLUMN rfc                  VARCHAR(13),
+    ADD COLUMN razon_social         VARCHAR(300),
+    ADD COLUMN regimen_fiscal       CHAR(3),
+    ADD COLUMN codigo_postal_fiscal CHAR(5),
+    ADD COLUMN uso_cfdi             VARCHAR(4);
+
+-- Key of the product in the SAT catalog, 01010101 means it is not in the catalog
+ALTER TABLE producto
+    ADD COLUMN clave_sat CHAR(8) NOT NULL DEFAULT '01010101';
+
+CREATE TABLE serie_factura (
+    serie        VARCHAR(25) PRIMARY KEY,
+    ultimo_folio INTEGER     NOT NULL
+);
+
+CREATE TABLE factura (
+    id_factura       SERIAL PRIMARY KEY,
+    id_venta         INTEGER        NOT NULL UNIQUE REFERENCES venta (id_venta),
+    serie            VARCHAR(25)    NOT NULL,
+    folio            INTEGER        NOT NULL,
+    estado           VARCHAR(10)    NOT NULL DEFAULT 'pending' CHECK (estado IN ('pending', 'stamped')),
+    fecha            TIMESTAMP      NOT NULL,
+    tasa_iva         NUMERIC(7, 6)  NOT NULL,
+    metodo_pago      CHAR(3),
+    forma_pago       CHAR(2),
+    rfc_receptor     VARCHAR(13)    NOT NULL,
+    nombre_receptor  VARCHAR(300)   NOT NULL,
+    regimen_receptor CHAR(3)        NOT NULL,
+    cp_receptor      CHAR(5)        NOT NULL,
+    uso_cfdi         VARCHAR(4)     NOT NULL,
+    uuid             CHAR(36) UNIQUE,
+    fecha_timbrado   TIMESTAMP,
+    xml              TEXT,
+    UNIQUE (serie, folio)
+);
+
+COMMIT;
@@ -0,0 +1,68 @@
+-- Append-only ledger (kardex) of every change of producto.stock.
+--
+-- A trigger writes it, so changes made by other triggers are recorded too. The application describes the
+-- movements of a transaction through the settings inventario.tipo, inventario.referencia and inventario.usuario;
+-- without them a change is recorded as an adjustment made by the database user. Products are not referenced with
+-- a foreign key so their history outlives them.
+
+BEGIN;
+
+CREATE TABLE movimiento_inventario (
+    id_movimiento BIGSERIAL PRIMARY KEY,
+    id_producto   INTEGER      NOT NULL,
+    fecha         TIMESTAMP    NOT NULL DEFAULT clock_timestamp(),
+    tipo          VARCHAR(10)  NOT NULL CHECK (tipo IN ('sale', 'return', 'delivery', 'adjustment', 'transfer')),
+    cantidad      INTEGER      NOT NULL,
+    saldo         INTEGER      NOT NULL,
+    referencia    VARCHAR(100),
+    usuario       VARCHAR(100) NOT NULL
+);
+
+CREATE INDEX movimiento_inventario_producto_idx ON movimiento_inventario (id_producto, id_movimiento);
+
+CREATE FUNCTION registrar_movimiento_inventario() RETURNS TRIGGER AS $$
+DECLARE
+    anterior INTEGER := 0;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        anterior := OLD.stock;
+    END IF;
+
+    IF NEW.stock IS NOT DISTINCT FROM anterior THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO movimiento_inventario (id_producto, tipo, cantidad, saldo, referencia, usuario)
+    VALUES (
+        NEW.id_producto,
+        COALESCE(NULLIF(current_setting('inventario.tipo', true), ''), 'adjustment'),
+        NEW.stock - anterior,
+        NEW.stock,
+        NULLIF(current_setting('inventario.referencia', true), ''),
+        COALESCE(NULLIF(current_setting('inventario.usuario', true), ''), current_user)
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER producto_movimiento_inventario
+    AFTER INSERT OR UPDATE OF stock ON producto
+    FOR EACH ROW EXECUTE FUNCTION registrar_movimiento_inventario();
+
+CREATE FUNCTION bloquear_movimiento_inventario() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'movimiento_inventario es de solo inserción';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER movimiento_inventario_solo_insercion
+    BEFORE UPDATE OR DELETE ON movimiento_inventario
+    FOR EACH ROW EXECUTE FUNCTION bloquear_movimiento_inventario();
+
+-- Current stock becomes the opening balance of every product
+INSERT INTO movimiento_inventario (id_producto, tipo, cantidad, saldo, referencia, usuario)
+SELECT id_producto, 'adjustment', stock, stock, 'saldo inicial', current_user
+FROM producto;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Minimum (reorder point) and maximum stock levels of every product, a minimum of 0 disables the alerts
+
+BEGIN;
+
+ALTER TABLE producto
+    ADD COLUMN stock_minimo INTEGER NOT NULL DEFAULT 0 CHECK (stock_minimo >= 0),
+    ADD COLUMN stock_maximo INTEGER NOT NULL DEFAULT 0 CHECK (stock_maximo >= 0);
+
+COMMIT;
@@ -0,0 +1,42 @@
+-- Purchase orders to providers, they replace the single pending delivery kept in producto_proveedor
+
+BEGIN;
+
+CREATE TABLE orden_compra (
+    id_orden       SERIAL PRIMARY KEY,
+    id_proveedor   INTEGER     NOT NULL REFERENCES proveedor (codigo),
+    estado         VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (estado IN ('draft', 'sent', 'partially_received', 'received', 'cancelled')),
+    fecha          DATE        NOT NULL DEFAULT CURRENT_DATE,
+    fecha_esperada DATE        NOT NULL
+);
+
+CREATE TABLE detalle_orden_compra (
+    id_detalle_orden  SERIAL PRIMARY KEY,
+    id_orden          INTEGER        NOT NULL REFERENCES orden_compra (id_orden) ON DELETE CASCADE,
+    id_producto       INTEGER        NOT NULL REFERENCES producto (id_producto),
+    cantidad          INTEGER        NOT NULL CHECK (cantidad > 0),
+    costo_unitario    NUMERIC(12, 2) NOT NULL,
+    cantidad_recibida INTEGER        NOT NULL DEFAULT 0 CHECK (cantidad_recibida BETWEEN 0 AND cantidad)
+);
+
+CREATE INDEX detalle_orden_compra_producto ON detalle_orden_compra (id_producto);
+
+-- Every pending delivery becomes a sent order. The old columns are left untouched, clearing them would fire the
+-- triggers that used to add the delivered stock
+CREATE TEMPORARY TABLE entrega_pendiente ON COMMIT DROP AS
+SELECT nextval(pg_get_serial_sequence('orden_compra', 'id_orden')) AS id_orden, pp.*
+FROM producto_proveedor pp
+WHERE pp.fecha_entrega IS NOT NULL AND pp.cantidad_surtir > 0;
+
+INSERT INTO orden_compra (id_orden, id_proveedor, estado, fecha, fecha_esperada)
+SELECT id_orden, id_proveedor, 'sent', CURRENT_DATE, fecha_entrega
+FROM entrega_pendiente;
+
+INSERT INTO detalle_orden_compra (id_orden, id_producto, cantidad, costo_unitario)
+SELECT e.id_orden, e.id_producto, e.cantidad_surtir, p.precio_proveedor
+FROM entrega_pendiente e
+INNER JOIN producto p
+    ON p.id_producto = e.id_producto;
+
+COMMIT;
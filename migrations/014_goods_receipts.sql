@@ -0,0 +1,26 @@
+-- Goods receipts against purchase orders. Accepted units go to stock and damaged ones to the damaged bucket, both
+-- count as received; rejected units go back to the provider and stay pending in the order
+
+BEGIN;
+
+CREATE TABLE recepcion (
+    id_recepcion SERIAL PRIMARY KEY,
+    id_orden     INTEGER      NOT NULL REFERENCES orden_compra (id_orden),
+    fecha        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    notas        VARCHAR(200) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE detalle_recepcion (
+    id_detalle_recepcion SERIAL PRIMARY KEY,
+    id_recepcion         INTEGER        NOT NULL REFERENCES recepcion (id_recepcion) ON DELETE CASCADE,
+    id_detalle_orden     INTEGER        NOT NULL REFERENCES detalle_orden_compra (id_detalle_orden),
+    cantidad             INTEGER        NOT NULL CHECK (cantidad >= 0),
+    danado               INTEGER        NOT NULL DEFAULT 0 CHECK (danado >= 0),
+    rechazado            INTEGER        NOT NULL DEFAULT 0 CHECK (rechazado >= 0),
+    costo_unitario       NUMERIC(12, 2) NOT NULL CHECK (costo_unitario >= 0),
+    CHECK (cantidad + danado + rechazado > 0)
+);
+
+CREATE INDEX recepcion_id_orden_idx ON recepcion (id_orden);
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Catalog of the providers of every product with their own SKU, cost and lead time. The preferred provider is the
+-- one shown with the product; only the new columns are written, so the legacy delivery triggers are not fired
+
+BEGIN;
+
+ALTER TABLE producto_proveedor
+    ADD COLUMN sku_proveedor VARCHAR(50)    NOT NULL DEFAULT '',
+    ADD COLUMN costo         NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (costo >= 0),
+    ADD COLUMN dias_entrega  INTEGER        NOT NULL DEFAULT 0 CHECK (dias_entrega >= 0),
+    ADD COLUMN preferido     BOOLEAN        NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX producto_proveedor_unico ON producto_proveedor (id_producto, id_proveedor);
+CREATE UNIQUE INDEX producto_proveedor_preferido ON producto_proveedor (id_producto) WHERE preferido;
+
+-- The provider each product had so far becomes its preferred one, bought at the provider price of the product
+UPDATE producto_proveedor pp
+SET costo = p.precio_proveedor, preferido = TRUE
+FROM producto p
+WHERE p.id_producto = pp.id_producto;
+
+COMMIT;
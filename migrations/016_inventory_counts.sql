@@ -0,0 +1,26 @@
+-- Physical inventory counts. A count snapshots the expected stock of its products, collects the counted units and,
+-- once posted, adjusts the stock of the approved lines by their variance with a reason
+
+BEGIN;
+
+CREATE TABLE conteo_inventario (
+    id_conteo     SERIAL PRIMARY KEY,
+    descripcion   VARCHAR(100) NOT NULL,
+    tipo          VARCHAR(10)  NOT NULL CHECK (tipo IN ('full', 'cycle')),
+    estado        VARCHAR(10)  NOT NULL DEFAULT 'open' CHECK (estado IN ('open', 'posted', 'cancelled')),
+    fecha_inicio  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_cierre  TIMESTAMP,
+    motivo        VARCHAR(100)
+);
+
+CREATE TABLE detalle_conteo (
+    id_detalle_conteo SERIAL PRIMARY KEY,
+    id_conteo         INTEGER NOT NULL REFERENCES conteo_inventario (id_conteo) ON DELETE CASCADE,
+    id_producto       INTEGER NOT NULL REFERENCES producto (id_producto),
+    esperado          INTEGER NOT NULL,
+    contado           INTEGER CHECK (contado >= 0),
+    ajuste            INTEGER,
+    UNIQUE (id_conteo, id_producto)
+);
+
+COMMIT;
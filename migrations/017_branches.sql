@@ -0,0 +1,109 @@
+-- Branches (sucursales) with their own stock and transfers between them.
+--
+-- existencia holds the stock of every product per branch and is the only place the application changes it. A
+-- trigger keeps producto.stock as the total of all branches, so the ledger trigger over producto.stock keeps
+-- recording every change, now with the branch it happened at. Units in transit between branches are not part of
+-- any branch nor of the total until they are received.
+
+BEGIN;
+
+CREATE TABLE sucursal (
+    id_sucursal SERIAL PRIMARY KEY,
+    nombre      VARCHAR(100) NOT NULL UNIQUE,
+    direccion   VARCHAR(200) NOT NULL DEFAULT ''
+);
+
+-- The store that existed so far becomes the main branch, it is the default location of every operation
+INSERT INTO sucursal (id_sucursal, nombre) VALUES (1, 'Matriz');
+SELECT setval(pg_get_serial_sequence('sucursal', 'id_sucursal'), 1);
+
+CREATE TABLE existencia (
+    id_sucursal INTEGER NOT NULL REFERENCES sucursal (id_sucursal),
+    id_producto INTEGER NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE,
+    stock       INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    PRIMARY KEY (id_sucursal, id_producto)
+);
+
+INSERT INTO existencia (id_sucursal, id_producto, stock)
+SELECT 1, id_producto, stock
+FROM producto
+WHERE stock > 0;
+
+CREATE FUNCTION existencia_total() RETURNS TRIGGER AS $$
+DECLARE
+    diferencia INTEGER := NEW.stock;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        diferencia := NEW.stock - OLD.stock;
+    END IF;
+
+    IF diferencia = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM set_config('inventario.sucursal', NEW.id_sucursal::TEXT, true);
+    UPDATE producto SET stock = stock + diferencia WHERE id_producto = NEW.id_producto;
+    PERFORM set_config('inventario.sucursal', '', true);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER existencia_total
+    AFTER INSERT OR UPDATE OF stock ON existencia
+    FOR EACH ROW EXECUTE FUNCTION existencia_total();
+
+ALTER TABLE movimiento_inventario ADD COLUMN id_sucursal INTEGER;
+
+CREATE OR REPLACE FUNCTION registrar_movimiento_inventario() RETURNS TRIGGER AS $$
+DECLARE
+    anterior INTEGER := 0;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        anterior := OLD.stock;
+    END IF;
+
+    IF NEW.stock IS NOT DISTINCT FROM anterior THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO movimiento_inventario (id_producto, tipo, cantidad, saldo, referencia, usuario, id_sucursal)
+    VALUES (
+        NEW.id_producto,
+        COALESCE(NULLIF(current_setting('inventario.tipo', true), ''), 'adjustment'),
+        NEW.stock - anterior,
+        NEW.stock,
+        NULLIF(current_setting('inventario.referencia', true), ''),
+        COALESCE(NULLIF(current_setting('inventario.usuario', true), ''), current_user),
+        NULLIF(current_setting('inventario.sucursal', true), '')::INTEGER
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Every document that moves stock names the branch it moves it at
+ALTER TABLE sesion_caja ADD COLUMN id_sucursal INTEGER NOT NULL DEFAULT 1 REFERENCES sucursal (id_sucursal);
+ALTER TABLE venta ADD COLUMN id_sucursal INTEGER NOT NULL DEFAULT 1 REFERENCES sucursal (id_sucursal);
+ALTER TABLE orden_compra ADD COLUMN id_sucursal INTEGER NOT NULL DEFAULT 1 REFERENCES sucursal (id_sucursal);
+ALTER TABLE conteo_inventario ADD COLUMN id_sucursal INTEGER NOT NULL DEFAULT 1 REFERENCES sucursal (id_sucursal);
+
+CREATE TABLE traspaso (
+    id_traspaso     SERIAL PRIMARY KEY,
+    id_origen       INTEGER      NOT NULL REFERENCES sucursal (id_sucursal),
+    id_destino      INTEGER      NOT NULL REFERENCES sucursal (id_sucursal),
+    estado          VARCHAR(10)  NOT NULL DEFAULT 'in_transit' CHECK (estado IN ('in_transit', 'received', 'cancelled')),
+    fecha_envio     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_recepcion TIMESTAMP,
+    notas           VARCHAR(200) NOT NULL DEFAULT '',
+    CHECK (id_origen <> id_destino)
+);
+
+CREATE TABLE detalle_traspaso (
+    id_detalle_traspaso SERIAL PRIMARY KEY,
+    id_traspaso         INTEGER NOT NULL REFERENCES traspaso (id_traspaso) ON DELETE CASCADE,
+    id_producto         INTEGER NOT NULL REFERENCES producto (id_producto),
+    cantidad            INTEGER NOT NULL CHECK (cantidad > 0)
+);
+
+COMMIT;
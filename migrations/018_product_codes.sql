@@ -0,0 +1,23 @@
+-- Codes a product is looked up by at the counter: our own SKU, the part number of its manufacturer, the original
+-- equipment (OEM) number and any number of EAN/UPC barcodes. Codes are stored trimmed and in upper case so lookups
+-- are exact matches over the indexes
+
+BEGIN;
+
+ALTER TABLE producto
+    ADD COLUMN sku          VARCHAR(40),
+    ADD COLUMN numero_parte VARCHAR(40),
+    ADD COLUMN numero_oem   VARCHAR(40);
+
+CREATE UNIQUE INDEX producto_sku ON producto (sku);
+CREATE INDEX producto_numero_parte ON producto (numero_parte);
+CREATE INDEX producto_numero_oem ON producto (numero_oem);
+
+CREATE TABLE codigo_barras (
+    codigo      VARCHAR(14) PRIMARY KEY,
+    id_producto INTEGER     NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE
+);
+
+CREATE INDEX codigo_barras_producto ON codigo_barras (id_producto);
+
+COMMIT;
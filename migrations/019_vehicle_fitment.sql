@@ -0,0 +1,29 @@
+-- Vehicle catalog and the vehicles every product fits. A vehicle is a model of a make sold in a range of years,
+-- an empty engine or trim means the entry covers all of them
+
+BEGIN;
+
+CREATE TABLE vehiculo (
+    id_vehiculo SERIAL PRIMARY KEY,
+    marca       VARCHAR(50) NOT NULL,
+    modelo      VARCHAR(50) NOT NULL,
+    anio_inicio INTEGER     NOT NULL,
+    anio_fin    INTEGER     NOT NULL,
+    motor       VARCHAR(50) NOT NULL DEFAULT '',
+    version     VARCHAR(50) NOT NULL DEFAULT '',
+    CHECK (anio_fin >= anio_inicio)
+);
+
+CREATE UNIQUE INDEX vehiculo_unico
+    ON vehiculo (LOWER(marca), LOWER(modelo), anio_inicio, anio_fin, LOWER(motor), LOWER(version));
+
+CREATE TABLE aplicacion (
+    id_producto INTEGER      NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE,
+    id_vehiculo INTEGER      NOT NULL REFERENCES vehiculo (id_vehiculo) ON DELETE CASCADE,
+    notas       VARCHAR(200) NOT NULL DEFAULT '',
+    PRIMARY KEY (id_producto, id_vehiculo)
+);
+
+CREATE INDEX aplicacion_vehiculo ON aplicacion (id_vehiculo);
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Parts a product can be replaced with: another of our products or the part number of another brand.
+--
+-- Exact and alternative equivalences work both ways between two of our products. A superseded one only goes from
+-- the old product, id_producto, to the one that replaces it
+
+BEGIN;
+
+CREATE TABLE equivalencia (
+    id_equivalencia SERIAL PRIMARY KEY,
+    id_producto     INTEGER      NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE,
+    id_equivalente  INTEGER      REFERENCES producto (id_producto) ON DELETE CASCADE,
+    numero_externo  VARCHAR(40),
+    marca_externa   VARCHAR(50)  NOT NULL DEFAULT '',
+    tipo            VARCHAR(11)  NOT NULL CHECK (tipo IN ('exact', 'alternative', 'superseded')),
+    notas           VARCHAR(200) NOT NULL DEFAULT '',
+    CHECK ((id_equivalente IS NULL) <> (numero_externo IS NULL)),
+    CHECK (id_equivalente <> id_producto)
+);
+
+CREATE UNIQUE INDEX equivalencia_producto ON equivalencia (id_producto, id_equivalente)
+    WHERE id_equivalente IS NOT NULL;
+CREATE UNIQUE INDEX equivalencia_externa ON equivalencia (id_producto, numero_externo, marca_externa)
+    WHERE numero_externo IS NOT NULL;
+CREATE INDEX equivalencia_equivalente ON equivalencia (id_equivalente);
+CREATE INDEX equivalencia_numero_externo ON equivalencia (numero_externo);
+
+COMMIT;
@@ -0,0 +1,70 @@
+-- Cost layers of the inventory, every inflow of units with a cost is a layer and every outflow consumes the oldest
+-- layers first. Consumptions are never deleted, giving units back is a negative consumption, so the layers left
+-- at any past date can be rebuilt.
+--
+-- The weighted average cost of every product is kept beside the layers together with its history, so the
+-- inventory can be valued by both methods at any date; sales record their cost by the method the store uses.
+
+BEGIN;
+
+ALTER TABLE producto ADD COLUMN costo_promedio NUMERIC(12, 4) NOT NULL DEFAULT 0;
+
+CREATE TABLE capa_costo (
+    id_capa        SERIAL PRIMARY KEY,
+    id_producto    INTEGER        NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE,
+    fecha          TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cantidad       INTEGER        NOT NULL CHECK (cantidad > 0),
+    restante       INTEGER        NOT NULL CHECK (restante BETWEEN 0 AND cantidad),
+    costo_unitario NUMERIC(12, 4) NOT NULL CHECK (costo_unitario >= 0),
+    referencia     VARCHAR(100)   NOT NULL DEFAULT ''
+);
+
+CREATE INDEX capa_costo_producto ON capa_costo (id_producto, fecha);
+
+CREATE TABLE consumo_capa (
+    id_consumo SERIAL PRIMARY KEY,
+    id_capa    INTEGER      NOT NULL REFERENCES capa_costo (id_capa) ON DELETE CASCADE,
+    id_detalle INTEGER      REFERENCES detalle_venta (id_detalle) ON DELETE SET NULL,
+    fecha      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cantidad   INTEGER      NOT NULL CHECK (cantidad <> 0),
+    referencia VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX consumo_capa_capa ON consumo_capa (id_capa, fecha);
+CREATE INDEX consumo_capa_detalle ON consumo_capa (id_detalle);
+
+CREATE TABLE historial_costo_promedio (
+    id_historial SERIAL PRIMARY KEY,
+    id_producto  INTEGER        NOT NULL REFERENCES producto (id_producto) ON DELETE CASCADE,
+    fecha        TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    costo        NUMERIC(12, 4) NOT NULL
+);
+
+CREATE INDEX historial_costo_promedio_producto ON historial_costo_promedio (id_producto, fecha);
+
+-- Cost of goods sold of every sale line and the part of it given back by returns to stock
+ALTER TABLE detalle_venta ADD COLUMN costo NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE detalle_devolucion ADD COLUMN costo NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+-- The units owned so far, in stock or in transit between branches, open a layer at the provider price
+UPDATE producto SET costo_promedio = precio_proveedor;
+
+INSERT INTO historial_costo_promedio (id_producto, costo)
+SELECT id_producto, costo_promedio
+FROM producto;
+
+INSERT INTO capa_costo (id_producto, cantidad, restante, costo_unitario, referencia)
+SELECT p.id_producto, p.stock + COALESCE(t.cantidad, 0), p.stock + COALESCE(t.cantidad, 0), p.precio_proveedor, 'saldo inicial'
+FROM producto p
+LEFT JOIN (
+    SELECT d.id_producto, SUM(d.cantidad) AS cantidad
+    FROM detalle_traspaso d
+    INNER JOIN traspaso t
+        ON t.id_traspaso = d.id_traspaso
+    WHERE t.estado = 'in_transit'
+    GROUP BY d.id_producto
+) t
+    ON t.id_producto = p.id_producto
+WHERE p.stock + COALESCE(t.cantidad, 0) > 0;
+
+COMMIT;
@@ -0,0 +1,73 @@
+-- Append-only history of the public and provider prices of every product.
+--
+-- A trigger writes it, so prices changed by receipts, bulk updates or by hand are all recorded. The application
+-- describes the changes of a transaction through the settings precio.origen, precio.referencia and
+-- precio.usuario; without them a change is recorded as a manual one made by the database user. Products are not
+-- referenced with a foreign key so their history outlives them.
+
+BEGIN;
+
+CREATE TABLE historial_precio (
+    id_historial BIGSERIAL PRIMARY KEY,
+    id_producto  INTEGER        NOT NULL,
+    fecha        TIMESTAMP      NOT NULL DEFAULT clock_timestamp(),
+    tipo         VARCHAR(10)    NOT NULL CHECK (tipo IN ('public', 'provider')),
+    anterior     NUMERIC(12, 2),
+    precio       NUMERIC(12, 2) NOT NULL,
+    origen       VARCHAR(10)    NOT NULL CHECK (origen IN ('manual', 'bulk', 'receipt')),
+    referencia   VARCHAR(100),
+    usuario      VARCHAR(100)   NOT NULL
+);
+
+CREATE INDEX historial_precio_producto_idx ON historial_precio (id_producto, id_historial);
+
+CREATE FUNCTION registrar_cambio_precio() RETURNS TRIGGER AS $$
+DECLARE
+    publico_anterior   NUMERIC;
+    proveedor_anterior NUMERIC;
+    origen             VARCHAR(10)  := COALESCE(NULLIF(current_setting('precio.origen', true), ''), 'manual');
+    referencia         VARCHAR(100) := NULLIF(current_setting('precio.referencia', true), '');
+    usuario            VARCHAR(100) := COALESCE(NULLIF(current_setting('precio.usuario', true), ''), current_user);
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        publico_anterior := OLD.precio_publico;
+        proveedor_anterior := OLD.precio_proveedor;
+    END IF;
+
+    IF TG_OP = 'INSERT' OR NEW.precio_publico IS DISTINCT FROM publico_anterior THEN
+        INSERT INTO historial_precio (id_producto, tipo, anterior, precio, origen, referencia, usuario)
+        VALUES (NEW.id_producto, 'public', publico_anterior, NEW.precio_publico, origen, referencia, usuario);
+    END IF;
+
+    IF TG_OP = 'INSERT' OR NEW.precio_proveedor IS DISTINCT FROM proveedor_anterior THEN
+        INSERT INTO historial_precio (id_producto, tipo, anterior, precio, origen, referencia, usuario)
+        VALUES (NEW.id_producto, 'provider', proveedor_anterior, NEW.precio_proveedor, origen, referencia, usuario);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER producto_historial_precio
+    AFTER INSERT OR UPDATE OF precio_publico, precio_proveedor ON producto
+    FOR EACH ROW EXECUTE FUNCTION registrar_cambio_precio();
+
+CREATE FUNCTION bloquear_historial_precio() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'historial_precio es de solo inserción';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER historial_precio_solo_insercion
+    BEFORE UPDATE OR DELETE ON historial_precio
+    FOR EACH ROW EXECUTE FUNCTION bloquear_historial_precio();
+
+-- Current prices become the opening entries of every product
+INSERT INTO historial_precio (id_producto, tipo, anterior, precio, origen, referencia, usuario)
+SELECT id_producto, 'public', NULL, precio_publico, 'manual', 'precio inicial', current_user
+FROM producto
+UNION ALL
+SELECT id_producto, 'provider', NULL, precio_proveedor, 'manual', 'precio inicial', current_user
+FROM producto;
+
+COMMIT;
@@ -0,0 +1,22 @@
+-- Markup rules scoped by category, brand or provider. A rule suggests the public price of a product as its
+-- provider price plus a markup, and sets the minimum margin over the public price a product may be sold with;
+-- a blocking rule rejects public prices under it, otherwise they are only warned about.
+
+BEGIN;
+
+CREATE TABLE regla_margen (
+    id_regla      SERIAL PRIMARY KEY,
+    id_categoria  INTEGER REFERENCES categoria (id_categoria) ON DELETE CASCADE,
+    marca         VARCHAR(100),
+    id_proveedor  INTEGER REFERENCES proveedor (codigo) ON DELETE CASCADE,
+    margen        NUMERIC(6, 2) NOT NULL CHECK (margen >= 0),
+    margen_minimo NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (margen_minimo >= 0 AND margen_minimo < 100),
+    bloquear      BOOLEAN       NOT NULL DEFAULT FALSE,
+    CHECK (num_nonnulls(id_categoria, marca, id_proveedor) = 1)
+);
+
+CREATE UNIQUE INDEX regla_margen_categoria ON regla_margen (id_categoria);
+CREATE UNIQUE INDEX regla_margen_marca ON regla_margen (LOWER(marca));
+CREATE UNIQUE INDEX regla_margen_proveedor ON regla_margen (id_proveedor);
+
+COMMIT;
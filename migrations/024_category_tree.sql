@@ -0,0 +1,34 @@
+-- Categories nest under a parent category (Frenos > Balatas > Delanteras), a category without parent is a root.
+-- Moving a category moves its whole subtree with it. Names are unique among the children of the same parent.
+--
+-- subcategorias returns a category with every category under it, and categorias_ascendentes a category with every
+-- category above it, nivel counting the steps up from the category itself.
+
+BEGIN;
+
+ALTER TABLE categoria
+    ADD COLUMN id_padre INTEGER REFERENCES categoria (id_categoria),
+    ADD CONSTRAINT categoria_padre_distinto CHECK (id_padre <> id_categoria);
+
+CREATE INDEX categoria_padre ON categoria (id_padre);
+CREATE UNIQUE INDEX categoria_nombre_hermanos ON categoria (COALESCE(id_padre, 0), LOWER(nombre_categoria));
+
+CREATE FUNCTION subcategorias(raiz INTEGER) RETURNS TABLE (id_categoria INTEGER) AS $$
+    WITH RECURSIVE arbol AS (
+        SELECT c.id_categoria FROM categoria c WHERE c.id_categoria = raiz
+        UNION ALL
+        SELECT c.id_categoria FROM categoria c INNER JOIN arbol a ON c.id_padre = a.id_categoria
+    )
+    SELECT arbol.id_categoria FROM arbol;
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION categorias_ascendentes(hoja INTEGER) RETURNS TABLE (id_categoria INTEGER, nivel INTEGER) AS $$
+    WITH RECURSIVE camino AS (
+        SELECT c.id_categoria, c.id_padre, 0 AS nivel FROM categoria c WHERE c.id_categoria = hoja
+        UNION ALL
+        SELECT c.id_categoria, c.id_padre, k.nivel + 1 FROM categoria c INNER JOIN camino k ON c.id_categoria = k.id_padre
+    )
+    SELECT camino.id_categoria, camino.nivel FROM camino;
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
@@ -0,0 +1,96 @@
+-- Part brands are their own catalog instead of the free text of producto.marca. A brand has a canonical name and
+-- aliases, the other spellings it is known by (AC Delco, ACDelco), which resolve to it when a product is saved.
+-- Products reference their brand by id; producto.marca is kept by a trigger as the name of the brand so listings,
+-- promotions and markup rules keep reading it.
+--
+-- Existing brands are mapped from producto.marca and from the brands of promotions and markup rules, ignoring case
+-- and surrounding spaces, the most used spelling becomes the name. Spellings that differ further become separate
+-- brands; deleting one of them merging it into the right one turns its name into an alias of it.
+--
+-- marca_canonica returns the name of the brand a text is the name or an alias of, or the trimmed text when no
+-- brand matches.
+
+BEGIN;
+
+CREATE TABLE marca (
+    id_marca SERIAL       PRIMARY KEY,
+    nombre   VARCHAR(100) NOT NULL CHECK (TRIM(nombre) <> '')
+);
+
+CREATE UNIQUE INDEX marca_nombre ON marca (LOWER(nombre));
+
+CREATE TABLE alias_marca (
+    id_alias SERIAL       PRIMARY KEY,
+    id_marca INTEGER      NOT NULL REFERENCES marca (id_marca) ON DELETE CASCADE,
+    alias    VARCHAR(100) NOT NULL CHECK (TRIM(alias) <> '')
+);
+
+CREATE UNIQUE INDEX alias_marca_alias ON alias_marca (LOWER(alias));
+CREATE INDEX alias_marca_marca ON alias_marca (id_marca);
+
+ALTER TABLE producto ADD COLUMN id_marca INTEGER REFERENCES marca (id_marca);
+
+CREATE INDEX producto_marca ON producto (id_marca);
+
+CREATE FUNCTION marca_canonica(texto VARCHAR) RETURNS VARCHAR AS $$
+    SELECT COALESCE(
+        (
+            SELECT m.nombre
+            FROM marca m
+            LEFT JOIN alias_marca a
+                ON a.id_marca = m.id_marca
+            WHERE LOWER(m.nombre) = LOWER(TRIM(texto)) OR LOWER(a.alias) = LOWER(TRIM(texto))
+            LIMIT 1
+        ),
+        TRIM(texto)
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION asignar_nombre_marca() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.id_marca IS NOT NULL THEN
+        SELECT nombre INTO NEW.marca FROM marca WHERE id_marca = NEW.id_marca;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER producto_nombre_marca
+    BEFORE INSERT OR UPDATE OF id_marca ON producto
+    FOR EACH ROW EXECUTE FUNCTION asignar_nombre_marca();
+
+-- Renaming a brand renames it on its products and on the promotions and markup rules scoped by it
+CREATE FUNCTION renombrar_marca() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE producto SET marca = NEW.nombre WHERE id_marca = NEW.id_marca;
+    UPDATE promocion SET marca = NEW.nombre WHERE LOWER(marca) = LOWER(OLD.nombre);
+    UPDATE regla_margen SET marca = NEW.nombre WHERE LOWER(marca) = LOWER(OLD.nombre);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER marca_renombrada
+    AFTER UPDATE OF nombre ON marca
+    FOR EACH ROW WHEN (OLD.nombre IS DISTINCT FROM NEW.nombre)
+    EXECUTE FUNCTION renombrar_marca();
+
+INSERT INTO marca (nombre)
+SELECT DISTINCT ON (LOWER(nombre)) nombre
+FROM (
+    SELECT TRIM(marca) AS nombre FROM producto
+    UNION ALL
+    SELECT TRIM(marca) FROM promocion WHERE marca IS NOT NULL
+    UNION ALL
+    SELECT TRIM(marca) FROM regla_margen WHERE marca IS NOT NULL
+) m
+WHERE COALESCE(nombre, '') <> ''
+GROUP BY nombre
+ORDER BY LOWER(nombre), COUNT(*) DESC, nombre;
+
+UPDATE producto p SET id_marca = m.id_marca FROM marca m WHERE LOWER(m.nombre) = LOWER(TRIM(p.marca));
+UPDATE promocion SET marca = marca_canonica(marca) WHERE marca IS NOT NULL;
+UPDATE regla_margen SET marca = marca_canonica(marca) WHERE marca IS NOT NULL;
+
+COMMIT;
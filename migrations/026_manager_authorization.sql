@@ -0,0 +1,20 @@
+-- Prices of a sale can only be overridden with the PIN of an active manager, the server checks it and records on
+-- the sale the manager that authorized it. PINs are stored as bcrypt hashes through pgcrypto.
+--
+-- Managers are registered by the administrator directly in database:
+--     INSERT INTO gerente (nombre, pin) VALUES ('Nombre', crypt('1234', gen_salt('bf')));
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE gerente (
+    id_gerente SERIAL       PRIMARY KEY,
+    nombre     VARCHAR(100) NOT NULL,
+    pin        TEXT         NOT NULL,
+    activo     BOOLEAN      NOT NULL DEFAULT TRUE
+);
+
+ALTER TABLE venta ADD COLUMN id_gerente INTEGER REFERENCES gerente (id_gerente);
+
+COMMIT;
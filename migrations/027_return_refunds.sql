@@ -0,0 +1,18 @@
+-- Returns are registered at an open register session. The refund first settles what is still owed on the sale
+-- and only the rest is handed out in cash from the drawer, so the cash expected at close subtracts it.
+--
+-- Returns recorded before went out in cash, except the ones over credit sales, which went to the client balance.
+
+BEGIN;
+
+ALTER TABLE devolucion
+    ADD COLUMN id_sesion INTEGER REFERENCES sesion_caja (id_sesion),
+    ADD COLUMN efectivo  NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+UPDATE devolucion dv SET efectivo = dv.reembolso FROM venta v WHERE v.id_venta = dv.id_venta AND NOT v.a_credito;
+
+ALTER TABLE devolucion ADD CONSTRAINT devolucion_efectivo CHECK (efectivo >= 0 AND efectivo <= reembolso);
+
+CREATE INDEX devolucion_sesion ON devolucion (id_sesion);
+
+COMMIT;